@@ -1,47 +1,113 @@
 package main
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
+	"net/url"
 	"regexp"
+	"sort"
 	"strings"
+	"text/template"
+
+	"github.com/expr-lang/expr/vm"
 )
 
-// Condition describes a single filter check against a pod's JSON fields.
-// Patterns are implicitly anchored with ^...$ before compilation.
+// Condition describes a single filter check against a resource's JSON fields.
+// Patterns are implicitly anchored with ^...$ before compilation. With Op set,
+// values are compared against Value, or the value at ValueFromPath, instead of
+// matched by valuePattern.
 type Condition struct {
-	Path         string `json:"path"`
-	KeyPattern   string `json:"keyPattern,omitempty"`
-	ValuePattern string `json:"valuePattern,omitempty"`
-	Invert       bool   `json:"invert,omitempty"`
+	Path            string      `json:"path"`
+	KeyPattern      string      `json:"keyPattern,omitempty"`
+	ValuePattern    string      `json:"valuePattern,omitempty"`
+	Op              string      `json:"op,omitempty"`              // eq, ne, gt, lt, ge, le, in, exists, semverGE, quantityGT, durationGT, newerThan, olderThan
+	Value           interface{} `json:"value,omitempty"`           // operand of op; a list for in, none for exists
+	ValueFromPath   string      `json:"valueFromPath,omitempty"`   // path in the same resource whose value is the operand; op defaults to eq
+	ValueTransforms []Transform `json:"valueTransforms,omitempty"` // applied to the valueFromPath value before comparing
+	Invert          bool        `json:"invert,omitempty"`
 
-	// compiled regexes (populated by ValidateConfig, not serialized)
+	// compiled regexes and op (populated by ValidateConfig, not serialized)
 	keyRe   *regexp.Regexp
 	valueRe *regexp.Regexp
+	opMatch func(string) bool
 }
 
+// ItemFilters is a group of checks that must all pass: every condition, the
+// expression, every allOf group, at least one anyOf group (when present) and
+// not the not group. Groups nest, so a plain conditions list is an implicit
+// allOf.
 type ItemFilters struct {
-	Conditions []Condition `json:"conditions,omitempty"`
+	Conditions []Condition   `json:"conditions,omitempty"`
+	Expression string        `json:"expression,omitempty"` // expr-lang boolean expression over the resource
+	AllOf      []ItemFilters `json:"allOf,omitempty"`
+	AnyOf      []ItemFilters `json:"anyOf,omitempty"`
+	Not        *ItemFilters  `json:"not,omitempty"`
+
+	// compiled expression (populated by ValidateConfig, not serialized)
+	program *vm.Program
 }
 
-// TemplateVar extracts a value from the pod JSON and appends it to the URL.
-// $VALUE in urlAppend is replaced with the resolved value.
+// TemplateVar extracts a value from the resource JSON and appends it to the URL.
+// $VALUE (or the var's own placeholder) in urlAppend is replaced with the
+// resolved value; other vars' placeholders can be referenced too. Values are
+// escaped for the part of the URL they land in unless Raw is set.
 type TemplateVar struct {
-	Path      string `json:"path"`      // dot-notation path into pod JSON (e.g. "metadata.labels.app", "spec.nodeName")
-	URLAppend string `json:"urlAppend"` // string appended to URL; $VALUE is replaced with the resolved value
+	Path        string `json:"path,omitempty"`        // dot-notation path into resource JSON (e.g. "metadata.labels.app", "spec.nodeName")
+	Value       string `json:"value,omitempty"`       // literal value used instead of path, e.g. "now" for a time transform
+	Key         string `json:"key,omitempty"`         // for map-valued paths: the key whose value to extract
+	Placeholder string `json:"placeholder,omitempty"` // name of this var's value in urlAppend, e.g. "$APP"; defaults to $VALUE
+	URLAppend   string `json:"urlAppend,omitempty"`   // string appended to URL with placeholders replaced; optional if placeholder is set
+	Raw         bool   `json:"raw,omitempty"`         // value is already URL-encoded; insert it unescaped
+
+	FallbackPaths []string    `json:"fallbackPaths,omitempty"` // paths tried in order when path (and key) don't resolve
+	Transforms    []Transform `json:"transforms,omitempty"`    // applied in order to the resolved value (not to default)
+	Default       string      `json:"default,omitempty"`       // value used when no path resolves or transforms yield ""
+	Required      bool        `json:"required,omitempty"`      // hide the item when the value (after default) is empty
+}
+
+// defaultPlaceholder refers to a templateVar's own value in its urlAppend.
+const defaultPlaceholder = "$VALUE"
+
+// placeholderRe matches placeholder references in urlAppend.
+var placeholderRe = regexp.MustCompile(`\$[A-Z][A-Z0-9_]*`)
+
+// ResolvedVar is a templateVar resolved against a resource.
+type ResolvedVar struct {
+	Path     string // templateVar path
+	Value    string // resolved value, unescaped
+	Appended string // urlAppend with the escaped value substituted
 }
 
 type MenuItem struct {
-	Description  string        `json:"description"`
-	Title        string        `json:"title"`
-	URL          string        `json:"url"`
+	ID           string        `json:"id,omitempty"`       // lets a later config layer override or disable the item
+	Disabled     bool          `json:"disabled,omitempty"` // drops the item with the same id from earlier layers
+	Description  string        `json:"description,omitempty"`
+	Title        string        `json:"title,omitempty"`
+	URL          string        `json:"url,omitempty"`
+	URLTemplate  string        `json:"urlTemplate,omitempty"` // text/template over the resource JSON; replaces url + templateVars
+	Kinds        []string      `json:"kinds,omitempty"`       // resource kinds this item applies to; empty means all
 	Filters      ItemFilters   `json:"filters,omitempty"`
 	TemplateVars []TemplateVar `json:"templateVars,omitempty"`
+	Use          []string      `json:"use,omitempty"` // conditionSets/templateVarSets added before filters.conditions and templateVars
+
+	// compiled urlTemplate (populated by ValidateConfig, not serialized)
+	urlTmpl *template.Template
+
+	// file the item was loaded from and its index there (set by LoadConfig)
+	source      string
+	sourceIndex int
 }
 
 type Config struct {
-	MenuItems []MenuItem `json:"menuItems"`
+	Schema   string            `json:"$schema,omitempty"`  // JSON Schema reference for editors; ignored
+	Include  []string          `json:"include,omitempty"`  // config files (or globs) layered below this one
+	Vars     map[string]string `json:"vars,omitempty"`     // values for ${name} in titles, descriptions and urls
+	Profiles []Profile         `json:"profiles,omitempty"` // per-context vars and url overrides
+
+	// named conditions and templateVars that menu items reference with use
+	ConditionSets   map[string][]Condition   `json:"conditionSets,omitempty"`
+	TemplateVarSets map[string][]TemplateVar `json:"templateVarSets,omitempty"`
+
+	MenuItems []MenuItem `json:"menuItems,omitempty"`
 }
 
 // anchorPattern wraps a pattern in ^...$ if not already anchored.
@@ -55,15 +121,12 @@ func anchorPattern(p string) string {
 	return p
 }
 
-// LoadConfig reads the JSON file at path, unmarshals it, and validates + compiles.
+// LoadConfig reads the config file at path (JSON, or YAML/TOML by extension)
+// and the files it includes, merges them, and validates + compiles.
 func LoadConfig(path string) (Config, error) {
-	data, err := os.ReadFile(path)
+	cfg, err := loadLayers(path, nil)
 	if err != nil {
-		return Config{}, fmt.Errorf("read config: %w", err)
-	}
-	var cfg Config
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return Config{}, fmt.Errorf("parse config: %w", err)
+		return Config{}, err
 	}
 	if err := ValidateConfig(&cfg); err != nil {
 		return Config{}, err
@@ -71,134 +134,597 @@ func LoadConfig(path string) (Config, error) {
 	return cfg, nil
 }
 
-// ValidateConfig checks that every MenuItem has a non-empty Title and URL,
-// validates and compiles regex patterns in conditions and templateVars.
+// ValidateConfig expands config vars, checks that every MenuItem has a
+// non-empty Title and a URL or urlTemplate, validates and compiles regex
+// patterns in conditions and templateVars, parses urlTemplates, and checks
+// that profile url overrides target items with a url.
 func ValidateConfig(cfg *Config) error {
 	if len(cfg.MenuItems) == 0 {
 		return fmt.Errorf("config: no menu items")
 	}
+	vars, err := resolveConfigVars(cfg.Vars, true)
+	if err != nil {
+		return err
+	}
+	lookup := varLookup(vars, true, true)
+	for i := range cfg.Profiles {
+		if err := validateProfile(i, &cfg.Profiles[i], lookup); err != nil {
+			return err
+		}
+	}
+	globals := cfg.globalPlaceholders()
 	for i := range cfg.MenuItems {
-		item := &cfg.MenuItems[i]
-		if item.Title == "" {
-			return fmt.Errorf("config: menuItems[%d] has empty title", i)
+		if err := cfg.applyUses(i, &cfg.MenuItems[i], true); err != nil {
+			return err
+		}
+		if err := expandItemVars(i, &cfg.MenuItems[i], lookup); err != nil {
+			return err
 		}
-		if item.URL == "" {
-			return fmt.Errorf("config: menuItems[%d] (%s) has empty url", i, item.Title)
+		if err := validateMenuItem(i, &cfg.MenuItems[i], globals); err != nil {
+			return err
 		}
-		for j := range item.Filters.Conditions {
-			cond := &item.Filters.Conditions[j]
-			if cond.Path == "" {
-				return fmt.Errorf("config: menuItems[%d] (%s) conditions[%d] has empty path", i, item.Title, j)
+	}
+	return cfg.validateProfileURLs()
+}
+
+// itemLabel names the i-th menu item in errors, with the file it came from
+// when that is known.
+func itemLabel(i int, item *MenuItem) string {
+	if item.source != "" {
+		return fmt.Sprintf("%s: menuItems[%d]", item.source, item.sourceIndex)
+	}
+	return fmt.Sprintf("menuItems[%d]", i)
+}
+
+// validateMenuItem validates and compiles the i-th menu item. globals are the
+// placeholders set outside the item (see validateTemplateVars).
+func validateMenuItem(i int, item *MenuItem, globals map[string]bool) error {
+	label := itemLabel(i, item)
+	if item.Title == "" {
+		return fmt.Errorf("config: %s has empty title", label)
+	}
+	if item.URL == "" && item.URLTemplate == "" {
+		return fmt.Errorf("config: %s (%s) has empty url", label, item.Title)
+	}
+	if item.URLTemplate != "" {
+		if len(item.TemplateVars) > 0 {
+			return fmt.Errorf("config: %s (%s) cannot use both urlTemplate and templateVars", label, item.Title)
+		}
+		tmpl, err := parseURLTemplate(item.Title, item.URLTemplate)
+		if err != nil {
+			return fmt.Errorf("config: %s (%s) invalid urlTemplate: %w", label, item.Title, err)
+		}
+		item.urlTmpl = tmpl
+	}
+	for j, kind := range item.Kinds {
+		if strings.TrimSpace(kind) == "" {
+			return fmt.Errorf("config: %s (%s) kinds[%d] is empty", label, item.Title, j)
+		}
+		item.Kinds[j] = NormalizeKind(kind)
+	}
+	if err := validateFilters(&item.Filters, fmt.Sprintf("%s (%s)", label, item.Title)); err != nil {
+		return err
+	}
+	return validateTemplateVars(item.TemplateVars, fmt.Sprintf("%s (%s)", label, item.Title), globals)
+}
+
+// validateTemplateVars checks paths and placeholders of an item's templateVars.
+// Named placeholders must be unique and every placeholder referenced in a
+// urlAppend must be $VALUE, defined by one of the vars or in globals. values
+// may only reference globals. A nil globals allows any reference, for linting
+// a config whose profiles may come from an include.
+func validateTemplateVars(vars []TemplateVar, where string, globals map[string]bool) error {
+	defined := map[string]bool{}
+	for j := range vars {
+		tv := &vars[j]
+		switch {
+		case tv.Path != "" && tv.Value != "":
+			return fmt.Errorf("config: %s templateVars[%d] cannot have both path and value", where, j)
+		case tv.Value != "":
+		case tv.Path == "":
+			return fmt.Errorf("config: %s templateVars[%d] has empty path", where, j)
+		default:
+			if _, err := parsePath(tv.Path); err != nil {
+				return fmt.Errorf("config: %s templateVars[%d] invalid path: %w", where, j, err)
 			}
-			// Default patterns
-			if cond.KeyPattern == "" {
-				cond.KeyPattern = ".*"
+		}
+		for k, p := range tv.FallbackPaths {
+			if _, err := parsePath(p); err != nil {
+				return fmt.Errorf("config: %s templateVars[%d] invalid fallbackPaths[%d]: %w", where, j, k, err)
 			}
-			if cond.ValuePattern == "" {
-				cond.ValuePattern = ".*"
+		}
+		for k := range tv.Transforms {
+			if err := tv.Transforms[k].compile(); err != nil {
+				return fmt.Errorf("config: %s templateVars[%d] transforms[%d]: %w", where, j, k, err)
 			}
-			// Compile with implicit anchoring
-			keyRe, err := regexp.Compile(anchorPattern(cond.KeyPattern))
-			if err != nil {
-				return fmt.Errorf("config: menuItems[%d] (%s) conditions[%d] invalid keyPattern %q: %w", i, item.Title, j, cond.KeyPattern, err)
+		}
+		if tv.URLAppend == "" && tv.Placeholder == "" {
+			return fmt.Errorf("config: %s templateVars[%d] has empty urlAppend", where, j)
+		}
+		for _, ref := range placeholderRe.FindAllString(tv.Value, -1) {
+			if globals != nil && !globals[ref] {
+				return fmt.Errorf("config: %s templateVars[%d] value references undefined placeholder %s", where, j, ref)
 			}
-			cond.keyRe = keyRe
-			valueRe, err := regexp.Compile(anchorPattern(cond.ValuePattern))
-			if err != nil {
-				return fmt.Errorf("config: menuItems[%d] (%s) conditions[%d] invalid valuePattern %q: %w", i, item.Title, j, cond.ValuePattern, err)
+		}
+		if tv.Placeholder == "" || tv.Placeholder == defaultPlaceholder {
+			continue
+		}
+		if placeholderRe.FindString(tv.Placeholder) != tv.Placeholder {
+			return fmt.Errorf("config: %s templateVars[%d] invalid placeholder %q (want $ followed by A-Z, 0-9 or _)", where, j, tv.Placeholder)
+		}
+		if defined[tv.Placeholder] || globals[tv.Placeholder] {
+			return fmt.Errorf("config: %s templateVars[%d] duplicate placeholder %s", where, j, tv.Placeholder)
+		}
+		defined[tv.Placeholder] = true
+	}
+	for j, tv := range vars {
+		for _, ref := range placeholderRe.FindAllString(tv.URLAppend, -1) {
+			if ref != defaultPlaceholder && !defined[ref] && globals != nil && !globals[ref] {
+				return fmt.Errorf("config: %s templateVars[%d] urlAppend references undefined placeholder %s", where, j, ref)
 			}
-			cond.valueRe = valueRe
 		}
-		for j, tv := range item.TemplateVars {
-			if tv.Path == "" {
-				return fmt.Errorf("config: menuItems[%d] (%s) templateVars[%d] has empty path", i, item.Title, j)
+	}
+	return nil
+}
+
+// validateFilters compiles every condition in f and its nested groups. where
+// prefixes error messages, e.g. "menuItems[0] (title) anyOf[1]".
+func validateFilters(f *ItemFilters, where string) error {
+	for j := range f.Conditions {
+		if err := compileCondition(&f.Conditions[j], fmt.Sprintf("%s conditions[%d]", where, j)); err != nil {
+			return err
+		}
+	}
+	if f.Expression != "" {
+		program, err := compileExpression(f.Expression)
+		if err != nil {
+			return fmt.Errorf("config: %s invalid expression: %w", where, err)
+		}
+		f.program = program
+	}
+	groups := []struct {
+		name   string
+		filter []ItemFilters
+	}{{"allOf", f.AllOf}, {"anyOf", f.AnyOf}}
+	for _, g := range groups {
+		for j := range g.filter {
+			sub := fmt.Sprintf("%s %s[%d]", where, g.name, j)
+			if g.filter[j].IsEmpty() {
+				return fmt.Errorf("config: %s is empty", sub)
 			}
-			if tv.URLAppend == "" {
-				return fmt.Errorf("config: menuItems[%d] (%s) templateVars[%d] has empty urlAppend", i, item.Title, j)
+			if err := validateFilters(&g.filter[j], sub); err != nil {
+				return err
 			}
 		}
 	}
+	if f.Not != nil {
+		sub := where + " not"
+		if f.Not.IsEmpty() {
+			return fmt.Errorf("config: %s is empty", sub)
+		}
+		if err := validateFilters(f.Not, sub); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// Evaluate checks whether this condition matches the given pod data.
-func (c *Condition) Evaluate(pd *PodData) bool {
-	val, ok := pd.ResolvePath(c.Path)
+// compileCondition applies default patterns and compiles the regexes of c.
+func compileCondition(cond *Condition, where string) error {
+	if cond.Path == "" {
+		return fmt.Errorf("config: %s has empty path", where)
+	}
+	if _, err := parsePath(cond.Path); err != nil {
+		return fmt.Errorf("config: %s invalid path: %w", where, err)
+	}
+	if cond.ValueFromPath != "" {
+		if err := compileValueFromPath(cond, where); err != nil {
+			return err
+		}
+	} else if len(cond.ValueTransforms) > 0 {
+		return fmt.Errorf("config: %s has valueTransforms but no valueFromPath", where)
+	} else if cond.Op != "" {
+		if cond.ValuePattern != "" {
+			return fmt.Errorf("config: %s cannot combine op and valuePattern", where)
+		}
+		match, err := compileOp(cond.Op, cond.Value)
+		if err != nil {
+			return fmt.Errorf("config: %s %w", where, err)
+		}
+		cond.opMatch = match
+	} else if cond.Value != nil {
+		return fmt.Errorf("config: %s has a value but no op", where)
+	}
+	// Default patterns, compiled with implicit anchoring. With an op (set by
+	// now for valueFromPath), values are compared by opMatch, so valuePattern
+	// stays empty and validating again doesn't reject the condition.
+	if cond.KeyPattern == "" {
+		cond.KeyPattern = ".*"
+	}
+	keyRe, err := regexp.Compile(anchorPattern(cond.KeyPattern))
+	if err != nil {
+		return fmt.Errorf("config: %s invalid keyPattern %q: %w", where, cond.KeyPattern, err)
+	}
+	cond.keyRe = keyRe
+	if cond.Op != "" {
+		return nil
+	}
+	if cond.ValuePattern == "" {
+		cond.ValuePattern = ".*"
+	}
+	valueRe, err := regexp.Compile(anchorPattern(cond.ValuePattern))
+	if err != nil {
+		return fmt.Errorf("config: %s invalid valuePattern %q: %w", where, cond.ValuePattern, err)
+	}
+	cond.valueRe = valueRe
+	return nil
+}
 
-	var matched bool
-	if !ok || val == nil {
-		matched = false
-	} else {
-		switch v := val.(type) {
-		case map[string]interface{}:
-			matched = c.matchMap(v)
-		case []interface{}:
-			matched = c.matchArray(v)
-		default:
-			matched = c.valueRe.MatchString(stringify(val))
+// compileValueFromPath validates a condition whose operand is read from the
+// resource. The op is compiled per resource, since the operand's type is only
+// known then.
+func compileValueFromPath(cond *Condition, where string) error {
+	if _, err := parsePath(cond.ValueFromPath); err != nil {
+		return fmt.Errorf("config: %s invalid valueFromPath: %w", where, err)
+	}
+	if cond.Value != nil || cond.ValuePattern != "" {
+		return fmt.Errorf("config: %s cannot combine valueFromPath with value or valuePattern", where)
+	}
+	if cond.Op == "" {
+		cond.Op = "eq"
+	}
+	if !knownOps[cond.Op] {
+		return fmt.Errorf("config: %s unknown op %q", where, cond.Op)
+	}
+	if cond.Op == "exists" {
+		return fmt.Errorf("config: %s op exists cannot use valueFromPath", where)
+	}
+	for k := range cond.ValueTransforms {
+		if err := cond.ValueTransforms[k].compile(); err != nil {
+			return fmt.Errorf("config: %s valueTransforms[%d]: %w", where, k, err)
 		}
 	}
+	return nil
+}
+
+// IsEmpty reports whether the filters contain no checks at all.
+func (f *ItemFilters) IsEmpty() bool {
+	return len(f.Conditions) == 0 && f.Expression == "" && len(f.AllOf) == 0 && len(f.AnyOf) == 0 && f.Not == nil
+}
 
+// Evaluate reports whether the resource passes every check in the group.
+// An empty group always passes.
+func (f *ItemFilters) Evaluate(r *Resource) bool {
+	for i := range f.Conditions {
+		if !f.Conditions[i].Evaluate(r) {
+			return false
+		}
+	}
+	if f.program != nil {
+		if matched, _ := runExpression(f.program, r); !matched {
+			return false
+		}
+	}
+	for i := range f.AllOf {
+		if !f.AllOf[i].Evaluate(r) {
+			return false
+		}
+	}
+	if len(f.AnyOf) > 0 {
+		matched := false
+		for i := range f.AnyOf {
+			if f.AnyOf[i].Evaluate(r) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if f.Not != nil && f.Not.Evaluate(r) {
+		return false
+	}
+	return true
+}
+
+// Evaluate checks whether this condition matches the given resource.
+func (c *Condition) Evaluate(r *Resource) bool {
+	val, ok := r.ResolvePath(c.Path)
+	if ok && c.ValueFromPath != "" {
+		var bound Condition
+		bound, _, ok = c.bindOperand(r)
+		c = &bound
+	}
+
+	matched := false
+	if ok {
+		_, matched = c.matchValue(val)
+	}
 	if c.Invert {
 		return !matched
 	}
 	return matched
 }
 
-// matchMap returns true if at least one map entry has a key matching keyRe
-// and a value matching valueRe.
-func (c *Condition) matchMap(m map[string]interface{}) bool {
-	for k, v := range m {
-		if c.keyRe.MatchString(k) && c.valueRe.MatchString(stringify(v)) {
-			return true
+// bindOperand returns a copy of c whose op compares against the value at
+// valueFromPath, and that operand. It fails if the path is missing or its
+// value doesn't suit the op (e.g. a non-numeric value for gt).
+func (c *Condition) bindOperand(r *Resource) (Condition, interface{}, bool) {
+	bound := *c
+	val, ok := r.ResolvePath(c.ValueFromPath)
+	if !ok {
+		return bound, nil, false
+	}
+	operand, ok := operandFromValue(c.Op, val, c.ValueTransforms)
+	if !ok {
+		return bound, nil, false
+	}
+	match, err := compileOp(c.Op, operand)
+	if err != nil {
+		return bound, operand, false
+	}
+	bound.opMatch = match
+	return bound, operand, true
+}
+
+// matchValue dispatches on the resolved type: maps match by key and value,
+// arrays (including lists collected by wildcard paths) match if any element
+// does, and scalars match their stringified value. nil never matches, except
+// that exists matches any map or array, even an empty one. It also returns
+// the matching entry ("key=value" for maps) for explain.
+func (c *Condition) matchValue(val interface{}) (string, bool) {
+	switch v := val.(type) {
+	case nil:
+		return "", false
+	case map[string]interface{}:
+		if c.Op == "exists" && c.KeyPattern == ".*" {
+			return "", true
 		}
+		return c.matchMap(v)
+	case []interface{}:
+		if c.Op == "exists" {
+			return "", true
+		}
+		return c.matchArray(v)
+	default:
+		s := stringify(val)
+		return s, c.matchScalar(s)
 	}
-	return false
 }
 
-// matchArray returns true if at least one element (stringified) matches valueRe.
-func (c *Condition) matchArray(arr []interface{}) bool {
+// matchMap finds the first map entry, in key order, with a key matching
+// keyRe and a matching value.
+func (c *Condition) matchMap(m map[string]interface{}) (string, bool) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		if c.keyRe.MatchString(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if v := stringify(m[k]); c.matchScalar(v) {
+			return k + "=" + v, true
+		}
+	}
+	return "", false
+}
+
+// matchScalar compares a stringified value using op, or valuePattern.
+func (c *Condition) matchScalar(s string) bool {
+	if c.opMatch != nil {
+		return c.opMatch(s)
+	}
+	return c.valueRe.MatchString(s)
+}
+
+// matchArray finds the first matching element.
+func (c *Condition) matchArray(arr []interface{}) (string, bool) {
 	for _, v := range arr {
-		if c.valueRe.MatchString(stringify(v)) {
+		if m, ok := c.matchValue(v); ok {
+			return m, true
+		}
+	}
+	return "", false
+}
+
+// AppliesToKind reports whether the item is enabled for the given resource kind.
+// Items without kinds apply to every kind.
+func (item MenuItem) AppliesToKind(kind string) bool {
+	if len(item.Kinds) == 0 {
+		return true
+	}
+	kind = NormalizeKind(kind)
+	for _, k := range item.Kinds {
+		if k == kind {
 			return true
 		}
 	}
 	return false
 }
 
-// MatchesPod returns true if all conditions in this item's filters pass.
-// If there are no conditions, always returns true.
-func (item MenuItem) MatchesPod(pd *PodData) bool {
-	for i := range item.Filters.Conditions {
-		if !item.Filters.Conditions[i].Evaluate(pd) {
-			return false
+// Matches returns true if the item applies to the resource's kind and its
+// filters pass. If there are no filters, only the kind is checked.
+func (item MenuItem) Matches(r *Resource) bool {
+	if !item.AppliesToKind(r.Kind) {
+		return false
+	}
+	return item.Filters.Evaluate(r)
+}
+
+// ResolveURL returns the item's URL built from resource data: the rendered
+// urlTemplate if set (falling back to url if rendering fails, see
+// TemplateError), otherwise url with templateVars appended.
+func (item MenuItem) ResolveURL(r *Resource) string {
+	if item.urlTmpl != nil {
+		if rendered, err := renderURLTemplate(item.urlTmpl, r); err == nil {
+			return rendered
 		}
+		return item.URL
 	}
-	return true
+	u := item.URL
+	for _, rv := range item.ResolveVars(r) {
+		u += rv.Appended
+	}
+	return u
 }
 
-// ResolveURL returns the item's URL with templateVars applied using pod data.
-func (item MenuItem) ResolveURL(pd *PodData) string {
-	url := item.URL
-	for _, tv := range item.TemplateVars {
-		val := tv.resolve(pd)
-		if val == "" {
+// ResolveVars resolves the item's templateVars in order. A var is skipped when
+// its own path doesn't resolve or its urlAppend references a placeholder that
+// didn't resolve. Each value is escaped according to where it lands in the
+// URL built so far.
+func (item MenuItem) ResolveVars(r *Resource) []ResolvedVar {
+	values := make([]string, len(item.TemplateVars))
+	named := map[string]int{} // placeholder -> index of the var that resolved it
+	for i, tv := range item.TemplateVars {
+		values[i] = tv.resolve(r)
+		if values[i] != "" && tv.Placeholder != "" {
+			named[tv.Placeholder] = i
+		}
+	}
+	lookup := func(ref string) (string, bool, bool) {
+		i, ok := named[ref]
+		if !ok {
+			v := r.Var(ref)
+			return v, false, v != ""
+		}
+		return values[i], item.TemplateVars[i].Raw, true
+	}
+
+	u := item.URL
+	var resolved []ResolvedVar
+	for i, tv := range item.TemplateVars {
+		if values[i] == "" {
 			continue
 		}
-		appendStr := strings.ReplaceAll(tv.URLAppend, "$VALUE", val)
-		url += appendStr
+		appended, ok := tv.expand(u, values[i], lookup)
+		if !ok {
+			continue
+		}
+		u += appended
+		resolved = append(resolved, ResolvedVar{Path: tv.displayPath(), Value: values[i], Appended: appended})
 	}
-	return url
+	return resolved
 }
 
-// resolve extracts the value for this template var from the pod data.
-func (tv TemplateVar) resolve(pd *PodData) string {
-	if pd == nil {
-		return ""
+// expand substitutes placeholders in urlAppend: $VALUE and the var's own
+// placeholder become val, other references are looked up with named. It
+// reports false if a referenced placeholder has no value. prefix is the URL
+// the result will be appended to; it decides whether a value is a path
+// segment, query value or fragment.
+func (tv TemplateVar) expand(prefix, val string, named func(ref string) (val string, raw, ok bool)) (string, bool) {
+	var b strings.Builder
+	last := 0
+	for _, loc := range placeholderRe.FindAllStringIndex(tv.URLAppend, -1) {
+		b.WriteString(tv.URLAppend[last:loc[0]])
+		last = loc[1]
+		ref := tv.URLAppend[loc[0]:loc[1]]
+		v, raw := val, tv.Raw
+		if ref != defaultPlaceholder && ref != tv.Placeholder {
+			var ok bool
+			if v, raw, ok = named(ref); !ok {
+				return "", false
+			}
+		}
+		if !raw {
+			v = escapeFor(prefix + b.String())(v)
+		}
+		b.WriteString(v)
 	}
-	val, ok := pd.ResolvePath(tv.Path)
-	if !ok {
+	b.WriteString(tv.URLAppend[last:])
+	return b.String(), true
+}
+
+// displayPath returns the var's path including its key, for previews.
+func (tv TemplateVar) displayPath() string {
+	if tv.Value != "" {
+		return "value"
+	}
+	if tv.Key == "" {
+		return tv.Path
+	}
+	return joinPath(tv.Path, tv.Key)
+}
+
+// escapeFor returns the escaping function for a value appended to partial:
+// query escaping after a '?', path escaping before it. Fragments are treated
+// like a URL of their own, so "#/logs?app=" query-escapes too.
+func escapeFor(partial string) func(string) string {
+	if inQuery(partial) {
+		return url.QueryEscape
+	}
+	return url.PathEscape
+}
+
+// inQuery reports whether text appended to partial lands in a query string,
+// of the URL or of its fragment.
+func inQuery(partial string) bool {
+	if i := strings.LastIndex(partial, "#"); i >= 0 {
+		partial = partial[i+1:]
+	}
+	return strings.Contains(partial, "?")
+}
+
+// TemplateError returns why the item's urlTemplate can't be rendered for r
+// when there is no url to fall back to. Such items are hidden, like items
+// with missing required vars.
+func (item MenuItem) TemplateError(r *Resource) error {
+	if item.urlTmpl == nil || item.URL != "" {
+		return nil
+	}
+	_, err := renderURLTemplate(item.urlTmpl, r)
+	return err
+}
+
+// MissingRequired returns the display paths of required templateVars that
+// resolve to an empty value for r. Items with missing required vars are hidden.
+func (item MenuItem) MissingRequired(r *Resource) []string {
+	var missing []string
+	for _, tv := range item.TemplateVars {
+		if tv.Required && tv.resolve(r) == "" {
+			missing = append(missing, tv.displayPath())
+		}
+	}
+	return missing
+}
+
+// resolve extracts the value for this template var from the resource data:
+// path (and key), then each fallback path, run through the transforms; the
+// default is used if that yields nothing.
+func (tv TemplateVar) resolve(r *Resource) string {
+	if val := tv.lookup(r); val != "" {
+		if val = applyTransforms(tv.Transforms, val); val != "" {
+			return val
+		}
+	}
+	return tv.Default
+}
+
+// lookup returns the literal value, or the first non-empty value among path
+// and fallbackPaths.
+func (tv TemplateVar) lookup(r *Resource) string {
+	if tv.Value != "" {
+		return placeholderRe.ReplaceAllStringFunc(tv.Value, r.Var)
+	}
+	if r == nil {
 		return ""
 	}
-	return stringify(val)
+	if val, ok := r.ResolvePath(tv.Path); ok {
+		if tv.Key != "" {
+			m, _ := val.(map[string]interface{})
+			val = m[tv.Key]
+		}
+		if s := stringify(val); s != "" {
+			return s
+		}
+	}
+	for _, p := range tv.FallbackPaths {
+		if val, ok := r.ResolvePath(p); ok {
+			if s := stringify(val); s != "" {
+				return s
+			}
+		}
+	}
+	return ""
 }
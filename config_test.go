@@ -1,25 +1,69 @@
 package main
 
 import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 )
 
-// podFromJSON is a test helper that creates a PodData from a raw JSON string.
-func podFromJSON(t *testing.T, raw string) *PodData {
+// TestMain keeps the remote config cache out of the user's cache directory.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "go-to-dashboard-cache-*")
+	if err != nil {
+		panic(err)
+	}
+	userCacheDir = func() (string, error) { return dir, nil }
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// resourceFromJSON is a test helper that creates a Resource of the given kind
+// from a raw JSON string.
+func resourceFromJSON(t *testing.T, kind, raw string) *Resource {
 	t.Helper()
 	var parsed map[string]interface{}
 	if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
-		t.Fatalf("podFromJSON: %v", err)
+		t.Fatalf("resourceFromJSON: %v", err)
 	}
-	return &PodData{
-		Name:      "test-pod",
+	return &Resource{
+		Kind:      kind,
+		Name:      "test-" + kind,
 		Namespace: "default",
 		RawJSON:   []byte(raw),
 		Parsed:    parsed,
 	}
 }
 
+// podFromJSON is a test helper that creates a pod Resource from a raw JSON string.
+func podFromJSON(t *testing.T, raw string) *Resource {
+	t.Helper()
+	return resourceFromJSON(t, "pod", raw)
+}
+
+// itemTitles is a test helper that returns the titles of items.
+func itemTitles(items []MenuItem) []string {
+	var got []string
+	for _, item := range items {
+		got = append(got, item.Title)
+	}
+	return got
+}
+
 // mustCompileCondition validates a single-item config to compile condition regexes.
 func mustCompileCondition(t *testing.T, c Condition) Condition {
 	t.Helper()
@@ -194,6 +238,225 @@ func TestResolvePath(t *testing.T) {
 	}
 }
 
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []string
+		wantErr bool
+	}{
+		{path: "metadata.name", want: []string{"metadata", "name"}},
+		{path: `metadata.labels["app.kubernetes.io/name"]`, want: []string{"metadata", "labels", "app.kubernetes.io/name"}},
+		{path: `metadata.annotations['prometheus.io/port']`, want: []string{"metadata", "annotations", "prometheus.io/port"}},
+		{path: `metadata.labels.app\.kubernetes\.io/name`, want: []string{"metadata", "labels", "app.kubernetes.io/name"}},
+		{path: `["a.b"].c`, want: []string{"a.b", "c"}},
+		{path: `a["x"]["y.z"].w`, want: []string{"a", "x", "y.z", "w"}},
+		{path: `a["say \"hi\""]`, want: []string{"a", `say "hi"`}},
+		{path: "", wantErr: true},
+		{path: "a..b", wantErr: true},
+		{path: "a.", wantErr: true},
+		{path: ".a", wantErr: true},
+		{path: `a["b"`, wantErr: true},
+		{path: `a["b]`, wantErr: true},
+		{path: `a[b]`, wantErr: true},
+		{path: "spec.containers.0.image", want: []string{"spec", "containers", "0", "image"}},
+		{path: "spec.containers[1].image", want: []string{"spec", "containers", "1", "image"}},
+		{path: "spec.containers[*].image", want: []string{"spec", "containers", "*", "image"}},
+		{path: "spec.containers[-1]", wantErr: true},
+		{path: `a["b"]c`, wantErr: true},
+		{path: `a\`, wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parsePath(tt.path)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parsePath(%q) = %v, want error", tt.path, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parsePath(%q) error: %v", tt.path, err)
+			continue
+		}
+		keys := make([]string, len(got))
+		for i, seg := range got {
+			keys[i] = seg.key
+		}
+		if strings.Join(keys, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("parsePath(%q) = %q, want %q", tt.path, keys, tt.want)
+		}
+	}
+}
+
+func TestResolvePath_DottedKeys(t *testing.T) {
+	pd := podFromJSON(t, podNginxProd)
+
+	for _, path := range []string{
+		`metadata.annotations["prometheus.io/port"]`,
+		`metadata.annotations['prometheus.io/port']`,
+		`metadata.annotations.prometheus\.io/port`,
+	} {
+		val, ok := pd.ResolvePath(path)
+		if !ok || val != "9090" {
+			t.Errorf("ResolvePath(%q) = %v, %v; want 9090, true", path, val, ok)
+		}
+	}
+	if _, ok := pd.ResolvePath("metadata.annotations.prometheus.io/port"); ok {
+		t.Error("unescaped dotted key should not resolve")
+	}
+	if _, ok := pd.ResolvePath(`metadata.annotations["unterminated`); ok {
+		t.Error("malformed path should not resolve")
+	}
+}
+
+func TestFlattenPaths_RoundTrip(t *testing.T) {
+	pd := podFromJSON(t, podNginxProd)
+
+	found := false
+	for _, line := range pd.FlattenPaths() {
+		path, want, _ := strings.Cut(line, " = ")
+		if path == `metadata.annotations['prometheus.io/port']` {
+			found = true
+		}
+		// Paths must paste into a JSON string value as they are
+		if quoted, _ := json.Marshal(path); string(quoted) != `"`+path+`"` {
+			t.Errorf("path %q needs escaping in JSON: %s", path, quoted)
+		}
+		val, ok := pd.ResolvePath(path)
+		if !ok || stringify(val) != want {
+			t.Errorf("ResolvePath(%q) = %v, %v; want %q", path, val, ok, want)
+		}
+	}
+	if !found {
+		t.Error("FlattenPaths should print dotted keys in bracket notation")
+	}
+}
+
+func TestResolvePath_ArraysAndWildcards(t *testing.T) {
+	pd := podFromJSON(t, podNginxProd)
+
+	tests := []struct {
+		path   string
+		want   string // stringified
+		wantOK bool
+	}{
+		{path: "spec.containers.0.image", want: "nginx:1.25", wantOK: true},
+		{path: "spec.containers[1].image", want: "envoy:1.28", wantOK: true},
+		{path: "spec.containers.2.image", wantOK: false},
+		{path: "spec.containers.#.image", want: "nginx:1.25,envoy:1.28", wantOK: true},
+		{path: "spec.containers[*].name", want: "nginx,sidecar", wantOK: true},
+		{path: "spec.containers.*.missing", wantOK: false},
+		{path: "metadata.labels.*", want: "nginx,production,platform", wantOK: true}, // map values in key order
+		{path: "spec.containers.name", wantOK: false},                                // arrays need an index
+		{path: "metadata.labels.0", wantOK: false},
+	}
+	for _, tt := range tests {
+		val, ok := pd.ResolvePath(tt.path)
+		if ok != tt.wantOK {
+			t.Errorf("ResolvePath(%q) ok = %v, want %v", tt.path, ok, tt.wantOK)
+			continue
+		}
+		if ok && stringify(val) != tt.want {
+			t.Errorf("ResolvePath(%q) = %q, want %q", tt.path, stringify(val), tt.want)
+		}
+	}
+
+	// Nested wildcards flatten into a single list
+	nested := podFromJSON(t, `{"spec": {"containers": [
+		{"ports": [{"containerPort": 80}, {"containerPort": 443}]},
+		{"ports": [{"containerPort": 9090}]}
+	]}}`)
+	val, ok := nested.ResolvePath("spec.containers.#.ports.#.containerPort")
+	if !ok || stringify(val) != "80,443,9090" {
+		t.Errorf("nested wildcard = %v, %v; want 80,443,9090", val, ok)
+	}
+}
+
+func TestConditionEvaluate_Wildcard(t *testing.T) {
+	pd := podFromJSON(t, podNginxProd)
+
+	tests := []struct {
+		name string
+		cond Condition
+		want bool
+	}{
+		{
+			name: "any container image matches",
+			cond: Condition{Path: "spec.containers.#.image", ValuePattern: "envoy:.*"},
+			want: true,
+		},
+		{
+			name: "no container image matches",
+			cond: Condition{Path: "spec.containers.#.image", ValuePattern: "redis:.*"},
+			want: false,
+		},
+		{
+			name: "indexed container",
+			cond: Condition{Path: "spec.containers.0.image", ValuePattern: "envoy:.*"},
+			want: false,
+		},
+		{
+			name: "array of maps matches by key and value",
+			cond: Condition{Path: "spec.containers", KeyPattern: "name", ValuePattern: "sidecar"},
+			want: true,
+		},
+		{
+			name: "invert wildcard",
+			cond: Condition{Path: "spec.containers.#.image", ValuePattern: "redis:.*", Invert: true},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := mustCompileCondition(t, tt.cond)
+			if got := c.Evaluate(pd); got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDottedKeyInConditionAndTemplateVar(t *testing.T) {
+	pd := podFromJSON(t, podNginxProd)
+
+	c := mustCompileCondition(t, Condition{Path: `metadata.annotations["prometheus.io/scrape"]`, ValuePattern: "true"})
+	if !c.Evaluate(pd) {
+		t.Error("condition on bracketed annotation key should match")
+	}
+
+	cfg := Config{MenuItems: []MenuItem{{
+		Title: "test", URL: "https://example.com",
+		TemplateVars: []TemplateVar{
+			{Path: `metadata.annotations["prometheus.io/port"]`, URLAppend: "?port=$VALUE"},
+		},
+	}}}
+	if err := ValidateConfig(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := cfg.MenuItems[0].ResolveURL(pd), "https://example.com?port=9090"; got != want {
+		t.Errorf("ResolveURL = %q, want %q", got, want)
+	}
+}
+
+func TestValidateConfig_InvalidPath(t *testing.T) {
+	cfg := Config{MenuItems: []MenuItem{{
+		Title: "test", URL: "http://test",
+		Filters: ItemFilters{Conditions: []Condition{
+			{Path: `metadata.labels["app`},
+		}},
+	}}}
+	if err := ValidateConfig(&cfg); err == nil {
+		t.Error("expected error for malformed condition path, got nil")
+	}
+
+	cfg = Config{MenuItems: []MenuItem{{
+		Title: "test", URL: "http://test",
+		TemplateVars: []TemplateVar{{Path: "metadata..name", URLAppend: "?x=$VALUE"}},
+	}}}
+	if err := ValidateConfig(&cfg); err == nil {
+		t.Error("expected error for malformed templateVar path, got nil")
+	}
+}
+
 // ---- Condition.Evaluate tests ----
 
 func TestConditionEvaluate_MapLabels(t *testing.T) {
@@ -413,9 +676,309 @@ func TestConditionEvaluate_MissingPath(t *testing.T) {
 	}
 }
 
-// ---- MatchesPod (AND logic) tests ----
+// ---- Comparison operators ----
+
+const podWithResources = `{
+  "metadata": {
+    "name": "api-0",
+    "labels": {"tier": "backend", "replicas": "3"},
+    "annotations": {"example.com/timeout": "90s"}
+  },
+  "spec": {
+    "containers": [
+      {
+        "name": "api",
+        "image": "registry.example.com:5000/team/api:v1.4.2",
+        "resources": {"limits": {"memory": "2Gi", "cpu": "500m"}}
+      },
+      {
+        "name": "proxy",
+        "image": "envoyproxy/envoy:1.3.0-rc.1@sha256:0123abcd",
+        "resources": {"limits": {"memory": "256Mi", "cpu": "1"}}
+      }
+    ]
+  },
+  "status": {
+    "containerStatuses": [
+      {"name": "api", "restartCount": 5, "ready": true},
+      {"name": "proxy", "restartCount": 0, "ready": false}
+    ]
+  }
+}`
+
+// conditionFromJSON compiles a condition written as config JSON, so operands
+// have the types the config loader produces.
+func conditionFromJSON(t *testing.T, raw string) Condition {
+	t.Helper()
+	var c Condition
+	if err := json.Unmarshal([]byte(raw), &c); err != nil {
+		t.Fatalf("conditionFromJSON: %v", err)
+	}
+	return mustCompileCondition(t, c)
+}
+
+func TestConditionEvaluate_Ops(t *testing.T) {
+	pd := podFromJSON(t, podWithResources)
+
+	tests := []struct {
+		name string
+		cond string
+		want bool
+	}{
+		{"gt any container", `{"path": "status.containerStatuses.#.restartCount", "op": "gt", "value": 3}`, true},
+		{"gt no container", `{"path": "status.containerStatuses.#.restartCount", "op": "gt", "value": 5}`, false},
+		{"ge boundary", `{"path": "status.containerStatuses.0.restartCount", "op": "ge", "value": 5}`, true},
+		{"lt", `{"path": "status.containerStatuses.1.restartCount", "op": "lt", "value": 1}`, true},
+		{"le", `{"path": "status.containerStatuses.0.restartCount", "op": "le", "value": 4}`, false},
+		{"gt on numeric string", `{"path": "metadata.labels", "keyPattern": "replicas", "op": "gt", "value": 2}`, true},
+		{"gt on non-number", `{"path": "metadata.name", "op": "gt", "value": 0}`, false},
+		{"eq string", `{"path": "spec.containers.0.name", "op": "eq", "value": "api"}`, true},
+		{"eq number", `{"path": "status.containerStatuses.0.restartCount", "op": "eq", "value": 5.0}`, true},
+		{"eq bool", `{"path": "status.containerStatuses.1.ready", "op": "eq", "value": false}`, true},
+		{"eq is not a pattern", `{"path": "spec.containers.0.name", "op": "eq", "value": "a.*"}`, false},
+		{"ne", `{"path": "metadata.labels", "keyPattern": "tier", "op": "ne", "value": "frontend"}`, true},
+		{"in", `{"path": "metadata.labels", "keyPattern": "tier", "op": "in", "value": ["frontend", "backend"]}`, true},
+		{"not in", `{"path": "metadata.labels", "keyPattern": "tier", "op": "in", "value": ["frontend", "db"]}`, false},
+		{"exists scalar", `{"path": "spec.containers.0.resources.limits.cpu", "op": "exists"}`, true},
+		{"exists map", `{"path": "metadata.annotations", "op": "exists"}`, true},
+		{"exists map key", `{"path": "metadata.labels", "keyPattern": "tier", "op": "exists"}`, true},
+		{"exists missing map key", `{"path": "metadata.labels", "keyPattern": "app", "op": "exists"}`, false},
+		{"exists missing path", `{"path": "spec.nodeName", "op": "exists"}`, false},
+		{"not exists", `{"path": "spec.nodeName", "op": "exists", "invert": true}`, true},
+		{"semverGE tag with v prefix", `{"path": "spec.containers.0.image", "op": "semverGE", "value": "1.4"}`, true},
+		{"semverGE too old", `{"path": "spec.containers.0.image", "op": "semverGE", "value": "1.5.0"}`, false},
+		{"semverGE pre-release sorts before release", `{"path": "spec.containers.1.image", "op": "semverGE", "value": "1.3.0"}`, false},
+		{"semverGE pre-release", `{"path": "spec.containers.1.image", "op": "semverGE", "value": "1.3.0-beta.2"}`, true},
+		{"quantityGT binary", `{"path": "spec.containers.#.resources.limits.memory", "op": "quantityGT", "value": "1Gi"}`, true},
+		{"quantityGT across units", `{"path": "spec.containers.1.resources.limits.memory", "op": "quantityGT", "value": "200M"}`, true},
+		{"quantityGT equal", `{"path": "spec.containers.0.resources.limits.memory", "op": "quantityGT", "value": "2048Mi"}`, false},
+		{"quantityGT millicores", `{"path": "spec.containers.0.resources.limits.cpu", "op": "quantityGT", "value": 0.25}`, true},
+		{"durationGT", `{"path": "metadata.annotations", "keyPattern": "example\\.com/timeout", "op": "durationGT", "value": "1m"}`, true},
+		{"durationGT not longer", `{"path": "metadata.annotations", "keyPattern": "example\\.com/timeout", "op": "durationGT", "value": "1m30s"}`, false},
+		{"inverted op", `{"path": "status.containerStatuses.#.restartCount", "op": "gt", "value": 3, "invert": true}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := conditionFromJSON(t, tt.cond)
+			if got := c.Evaluate(pd); got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateConfig_Ops(t *testing.T) {
+	tests := []struct {
+		name string
+		cond string
+	}{
+		{"unknown op", `{"path": "spec.nodeName", "op": "matches", "value": "x"}`},
+		{"op with valuePattern", `{"path": "spec.nodeName", "op": "eq", "value": "x", "valuePattern": "x"}`},
+		{"value without op", `{"path": "spec.nodeName", "value": "x"}`},
+		{"missing operand", `{"path": "spec.nodeName", "op": "eq"}`},
+		{"exists with operand", `{"path": "spec.nodeName", "op": "exists", "value": true}`},
+		{"gt with string", `{"path": "status.restartCount", "op": "gt", "value": "3"}`},
+		{"eq with object", `{"path": "spec.nodeName", "op": "eq", "value": {"a": 1}}`},
+		{"in with scalar", `{"path": "spec.nodeName", "op": "in", "value": "x"}`},
+		{"in with empty list", `{"path": "spec.nodeName", "op": "in", "value": []}`},
+		{"in with nested list", `{"path": "spec.nodeName", "op": "in", "value": [["x"]]}`},
+		{"invalid semver", `{"path": "spec.containers.0.image", "op": "semverGE", "value": "latest"}`},
+		{"invalid quantity", `{"path": "spec.containers.0.resources.limits.memory", "op": "quantityGT", "value": "2GB"}`},
+		{"invalid duration", `{"path": "metadata.annotations.timeout", "op": "durationGT", "value": 60}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c Condition
+			if err := json.Unmarshal([]byte(tt.cond), &c); err != nil {
+				t.Fatal(err)
+			}
+			cfg := Config{MenuItems: []MenuItem{{Title: "test", URL: "http://test", Filters: ItemFilters{Conditions: []Condition{c}}}}}
+			if err := ValidateConfig(&cfg); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestValidateConfig_Twice(t *testing.T) {
+	data := `{"menuItems": [{"title": "a", "url": "https://a", "filters": {"conditions": [
+  {"path": "status.phase", "op": "eq", "value": "Running"},
+  {"path": "metadata.namespace", "valueFromPath": "metadata.labels.env"},
+  {"path": "metadata.labels", "keyPattern": "team"}
+]}}]}`
+	var cfg Config
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := ValidateConfig(&cfg); err != nil {
+			t.Fatalf("validation %d: %v", i+1, err)
+		}
+	}
+	if !cfg.MenuItems[0].Matches(podFromJSON(t, podNginxProd)) {
+		t.Error("item doesn't match after validating twice")
+	}
+}
+
+func TestParseQuantity(t *testing.T) {
+	tests := []struct {
+		in   string
+		want float64
+	}{
+		{"500m", 0.5},
+		{"2", 2},
+		{"1k", 1000},
+		{"1Ki", 1024},
+		{"1.5Gi", 1.5 * (1 << 30)},
+		{"128974848", 128974848},
+		{"129e6", 129e6},
+		{"1E", 1e18},
+	}
+	for _, tt := range tests {
+		got, err := parseQuantity(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("parseQuantity(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+	for _, in := range []string{"", "Gi", "2GB", "abc"} {
+		if _, err := parseQuantity(in); err == nil {
+			t.Errorf("parseQuantity(%q): expected error", in)
+		}
+	}
+}
+
+func TestConditionEvaluate_TimeOps(t *testing.T) {
+	pd := podFromJSON(t, podRestarted)
+
+	tests := []struct {
+		name string
+		now  string
+		cond string
+		want bool
+	}{
+		{"restarted in the last hour", "2026-10-16T12:00:00Z", `{"path": "status.containerStatuses.#.lastState.terminated.finishedAt", "op": "newerThan", "value": "1h"}`, true},
+		{"no restart in the last hour", "2026-10-16T13:00:00Z", `{"path": "status.containerStatuses.#.lastState.terminated.finishedAt", "op": "newerThan", "value": "1h"}`, false},
+		{"created less than 10 minutes ago", "2026-10-16T10:05:00Z", `{"path": "metadata.creationTimestamp", "op": "newerThan", "value": "10m"}`, true},
+		{"created more than 10 minutes ago", "2026-10-16T10:15:00Z", `{"path": "metadata.creationTimestamp", "op": "newerThan", "value": "10m"}`, false},
+		{"older than a day", "2026-10-17T10:00:06Z", `{"path": "status.startTime", "op": "olderThan", "value": "24h"}`, true},
+		{"not older than a day", "2026-10-16T12:00:00Z", `{"path": "status.startTime", "op": "olderThan", "value": "24h"}`, false},
+		{"not a timestamp", "2026-10-16T12:00:00Z", `{"path": "metadata.name", "op": "olderThan", "value": "1m"}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixClock(t, tt.now)
+			c := conditionFromJSON(t, tt.cond)
+			if got := c.Evaluate(pd); got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	for _, bad := range []string{
+		`{"path": "metadata.creationTimestamp", "op": "newerThan", "value": 600}`,
+		`{"path": "metadata.creationTimestamp", "op": "newerThan", "value": "10 minutes"}`,
+		`{"path": "metadata.creationTimestamp", "op": "olderThan", "value": "-1h"}`,
+	} {
+		var c Condition
+		if err := json.Unmarshal([]byte(bad), &c); err != nil {
+			t.Fatal(err)
+		}
+		cfg := Config{MenuItems: []MenuItem{{Title: "test", URL: "http://test", Filters: ItemFilters{Conditions: []Condition{c}}}}}
+		if err := ValidateConfig(&cfg); err == nil {
+			t.Errorf("%s: expected error, got nil", bad)
+		}
+	}
+}
+
+// ---- Cross-field conditions ----
+
+const podCrossField = `{
+  "metadata": {
+    "name": "api-0",
+    "labels": {"version": "1.4.2", "minReplicas": "2", "zones": "eu-west-1a"},
+    "annotations": {"example.com/expected-host": "10.0.0.7", "example.com/min-version": "1.5"}
+  },
+  "spec": {
+    "replicas": 3,
+    "containers": [{"name": "api", "image": "registry.example.com/team/api:1.5.0"}]
+  },
+  "status": {
+    "hostIP": "10.0.0.7",
+    "readyReplicas": 3,
+    "availableZones": ["eu-west-1a", "eu-west-1b"]
+  }
+}`
+
+func TestConditionEvaluate_ValueFromPath(t *testing.T) {
+	pd := podFromJSON(t, podCrossField)
+
+	tests := []struct {
+		name string
+		cond string
+		want bool
+	}{
+		{"eq is the default op", `{"path": "status.hostIP", "valueFromPath": "metadata.annotations[\"example.com/expected-host\"]"}`, true},
+		{"label differs from image tag", `{"path": "metadata.labels.version", "op": "ne", "valueFromPath": "spec.containers.0.image",
+			"valueTransforms": [{"type": "split", "separator": ":", "index": -1}]}`, true},
+		{"label matches image tag", `{"path": "metadata.labels.version", "valueFromPath": "spec.containers.0.image",
+			"valueTransforms": [{"type": "split", "separator": ":", "index": -1}]}`, false},
+		{"numbers compare numerically", `{"path": "status.readyReplicas", "op": "ge", "valueFromPath": "spec.replicas"}`, true},
+		{"numeric string operand", `{"path": "spec.replicas", "op": "gt", "valueFromPath": "metadata.labels.minReplicas"}`, true},
+		{"semverGE against image tag", `{"path": "metadata.labels.version", "op": "semverGE", "valueFromPath": "spec.containers.0.image"}`, false},
+		{"semverGE from annotation", `{"path": "spec.containers.0.image", "op": "semverGE", "valueFromPath": "metadata.annotations[\"example.com/min-version\"]"}`, true},
+		{"in list from path", `{"path": "metadata.labels.zones", "op": "in", "valueFromPath": "status.availableZones"}`, true},
+		{"missing operand path", `{"path": "status.hostIP", "valueFromPath": "spec.nodeName"}`, false},
+		{"missing operand path inverted", `{"path": "status.hostIP", "valueFromPath": "spec.nodeName", "invert": true}`, true},
+		{"list operand needs in", `{"path": "metadata.labels.zones", "valueFromPath": "status.availableZones"}`, false},
+		{"non-numeric operand", `{"path": "spec.replicas", "op": "gt", "valueFromPath": "metadata.name"}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := conditionFromJSON(t, tt.cond)
+			if got := c.Evaluate(pd); got != tt.want {
+				t.Errorf("Evaluate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// The bound operand must not leak into the shared condition
+	c := conditionFromJSON(t, `{"path": "status.hostIP", "valueFromPath": "metadata.annotations[\"example.com/expected-host\"]"}`)
+	other := podFromJSON(t, `{"status": {"hostIP": "10.0.0.7"}, "metadata": {"annotations": {"example.com/expected-host": "10.0.0.9"}}}`)
+	if !c.Evaluate(pd) || c.Evaluate(other) {
+		t.Error("operand from one resource was reused for another")
+	}
+}
+
+func TestValidateConfig_ValueFromPath(t *testing.T) {
+	tests := []struct {
+		name string
+		cond string
+	}{
+		{"invalid path", `{"path": "status.hostIP", "valueFromPath": "spec..nodeName"}`},
+		{"with value", `{"path": "status.hostIP", "valueFromPath": "spec.nodeName", "value": "x"}`},
+		{"with valuePattern", `{"path": "status.hostIP", "valueFromPath": "spec.nodeName", "valuePattern": "x"}`},
+		{"unknown op", `{"path": "status.hostIP", "op": "like", "valueFromPath": "spec.nodeName"}`},
+		{"exists", `{"path": "status.hostIP", "op": "exists", "valueFromPath": "spec.nodeName"}`},
+		{"invalid transform", `{"path": "status.hostIP", "valueFromPath": "spec.nodeName", "valueTransforms": [{"type": "split"}]}`},
+		{"transforms without valueFromPath", `{"path": "status.hostIP", "op": "eq", "value": "x", "valueTransforms": [{"type": "lower"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var c Condition
+			if err := json.Unmarshal([]byte(tt.cond), &c); err != nil {
+				t.Fatal(err)
+			}
+			cfg := Config{MenuItems: []MenuItem{{Title: "test", URL: "http://test", Filters: ItemFilters{Conditions: []Condition{c}}}}}
+			if err := ValidateConfig(&cfg); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+// ---- Matches (AND logic) tests ----
 
-func TestMatchesPod(t *testing.T) {
+func TestMatches(t *testing.T) {
 	nginx := podFromJSON(t, podNginxProd)
 	redis := podFromJSON(t, podRedisStaging)
 
@@ -433,24 +996,24 @@ func TestMatchesPod(t *testing.T) {
 	}
 	item = cfg.MenuItems[0]
 
-	if !item.MatchesPod(nginx) {
+	if !item.Matches(nginx) {
 		t.Error("nginx-prod pod should match app=nginx AND env=production")
 	}
-	if item.MatchesPod(redis) {
+	if item.Matches(redis) {
 		t.Error("redis-staging pod should NOT match app=nginx AND env=production")
 	}
 }
 
-func TestMatchesPod_NoConditions(t *testing.T) {
+func TestMatches_NoConditions(t *testing.T) {
 	pd := podFromJSON(t, podNginxProd)
 	item := MenuItem{Title: "test", URL: "http://test"}
 	// No conditions → always matches
-	if !item.MatchesPod(pd) {
+	if !item.Matches(pd) {
 		t.Error("item with no conditions should match any pod")
 	}
 }
 
-func TestMatchesPod_MixedPathTypes(t *testing.T) {
+func TestMatches_MixedPathTypes(t *testing.T) {
 	pd := podFromJSON(t, podNginxProd)
 
 	// Require label app=nginx AND status.phase=Running AND nodeName starts with prod-
@@ -467,187 +1030,2266 @@ func TestMatchesPod_MixedPathTypes(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	if !cfg.MenuItems[0].MatchesPod(pd) {
+	if !cfg.MenuItems[0].Matches(pd) {
 		t.Error("nginx-prod should match all three conditions")
 	}
 
 	// Same conditions against redis-staging → should fail
 	redis := podFromJSON(t, podRedisStaging)
-	if cfg.MenuItems[0].MatchesPod(redis) {
+	if cfg.MenuItems[0].Matches(redis) {
 		t.Error("redis-staging should NOT match (app!=nginx, node!=prod-*)")
 	}
 }
 
-// ---- FilterMenuItems tests ----
+// ---- Filter groups (allOf / anyOf / not) ----
 
-func TestFilterMenuItems_NilPod(t *testing.T) {
-	items := []MenuItem{
-		{Title: "With filter", URL: "http://a", Filters: ItemFilters{Conditions: []Condition{
-			{Path: "metadata.labels", KeyPattern: "app"},
-		}}},
-		{Title: "No filter", URL: "http://b"},
+func TestItemFilters_Groups(t *testing.T) {
+	nginx := podFromJSON(t, podNginxProd)
+	redis := podFromJSON(t, podRedisStaging)
+	bare := podFromJSON(t, podNoLabels)
+
+	appIs := func(v string) Condition {
+		return Condition{Path: "metadata.labels", KeyPattern: "app", ValuePattern: v}
 	}
-	cfg := Config{MenuItems: items}
-	if err := ValidateConfig(&cfg); err != nil {
-		t.Fatal(err)
+	envIs := func(v string) Condition {
+		return Condition{Path: "metadata.labels", KeyPattern: "env", ValuePattern: v}
 	}
 
-	result := FilterMenuItems(cfg.MenuItems, nil)
-	if len(result) != 1 || result[0].Title != "No filter" {
-		t.Errorf("nil pod: got %d items, want 1 (No filter)", len(result))
-	}
-}
+	tests := []struct {
+		name    string
+		filters ItemFilters
+		want    map[string]bool // pod name -> should match
+	}{
+		{
+			name: "anyOf: app=nginx OR app=redis",
+			filters: ItemFilters{AnyOf: []ItemFilters{
+				{Conditions: []Condition{appIs("nginx")}},
+				{Conditions: []Condition{appIs("redis")}},
+			}},
+			want: map[string]bool{"nginx": true, "redis": true, "bare": false},
+		},
+		{
+			name: "conditions AND anyOf: prod AND (nginx OR envoy)",
+			filters: ItemFilters{
+				Conditions: []Condition{envIs("production")},
+				AnyOf: []ItemFilters{
+					{Conditions: []Condition{appIs("nginx")}},
+					{Conditions: []Condition{appIs("envoy")}},
+				},
+			},
+			want: map[string]bool{"nginx": true, "redis": false, "bare": false},
+		},
+		{
+			name: "allOf groups",
+			filters: ItemFilters{AllOf: []ItemFilters{
+				{Conditions: []Condition{appIs("nginx")}},
+				{Conditions: []Condition{{Path: "status.phase", ValuePattern: "Running"}}},
+			}},
+			want: map[string]bool{"nginx": true, "redis": false, "bare": false},
+		},
+		{
+			name:    "not: anything but staging",
+			filters: ItemFilters{Not: &ItemFilters{Conditions: []Condition{envIs("staging")}}},
+			want:    map[string]bool{"nginx": true, "redis": false, "bare": true},
+		},
+		{
+			name: "nested: not (app=redis OR phase=Pending)",
+			filters: ItemFilters{Not: &ItemFilters{AnyOf: []ItemFilters{
+				{Conditions: []Condition{appIs("redis")}},
+				{Conditions: []Condition{{Path: "status.phase", ValuePattern: "Pending"}}},
+			}}},
+			want: map[string]bool{"nginx": true, "redis": false, "bare": false},
+		},
+	}
 
-func TestFilterMenuItems_WithPod(t *testing.T) {
-	pd := podFromJSON(t, podNginxProd)
+	pods := map[string]*Resource{"nginx": nginx, "redis": redis, "bare": bare}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{MenuItems: []MenuItem{{Title: "test", URL: "http://test", Filters: tt.filters}}}
+			if err := ValidateConfig(&cfg); err != nil {
+				t.Fatal(err)
+			}
+			for name, pd := range pods {
+				if got := cfg.MenuItems[0].Matches(pd); got != tt.want[name] {
+					t.Errorf("%s: Matches() = %v, want %v", name, got, tt.want[name])
+				}
+			}
+		})
+	}
+}
 
-	items := []MenuItem{
-		{Title: "Matches", URL: "http://a", Filters: ItemFilters{Conditions: []Condition{
-			{Path: "metadata.labels", KeyPattern: "app", ValuePattern: "nginx"},
-		}}},
-		{Title: "No match", URL: "http://b", Filters: ItemFilters{Conditions: []Condition{
-			{Path: "metadata.labels", KeyPattern: "app", ValuePattern: "redis"},
+func TestItemFilters_GroupsFromJSON(t *testing.T) {
+	raw := `{"menuItems": [{
+		"title": "Nginx or Envoy in prod", "url": "http://test",
+		"filters": {
+			"conditions": [{ "path": "metadata.labels", "keyPattern": "env", "valuePattern": "production" }],
+			"anyOf": [
+				{ "conditions": [{ "path": "metadata.labels", "keyPattern": "app", "valuePattern": "nginx" }] },
+				{ "conditions": [{ "path": "metadata.labels", "keyPattern": "app", "valuePattern": "envoy" }] }
+			],
+			"not": { "conditions": [{ "path": "status.phase", "valuePattern": "Failed" }] }
+		}
+	}]}`
+	var cfg Config
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateConfig(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.MenuItems[0].Matches(podFromJSON(t, podNginxProd)) {
+		t.Error("nginx-prod should match")
+	}
+	if cfg.MenuItems[0].Matches(podFromJSON(t, podRedisStaging)) {
+		t.Error("redis-staging should not match")
+	}
+}
+
+func TestValidateConfig_Groups(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters ItemFilters
+	}{
+		{"invalid regex in anyOf", ItemFilters{AnyOf: []ItemFilters{{Conditions: []Condition{{Path: "a", KeyPattern: "[bad"}}}}}},
+		{"empty path in nested not", ItemFilters{AllOf: []ItemFilters{{Not: &ItemFilters{Conditions: []Condition{{}}}}}}},
+		{"empty anyOf group", ItemFilters{AnyOf: []ItemFilters{{}}}},
+		{"empty not group", ItemFilters{Not: &ItemFilters{}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{MenuItems: []MenuItem{{Title: "test", URL: "http://test", Filters: tt.filters}}}
+			if err := ValidateConfig(&cfg); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+
+	// Nested conditions get their default patterns compiled
+	cfg := Config{MenuItems: []MenuItem{{Title: "test", URL: "http://test", Filters: ItemFilters{
+		Not: &ItemFilters{Conditions: []Condition{{Path: "metadata.labels"}}},
+	}}}}
+	if err := ValidateConfig(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cond := cfg.MenuItems[0].Filters.Not.Conditions[0]; cond.keyRe == nil || cond.valueRe == nil {
+		t.Error("nested condition regexes were not compiled")
+	}
+}
+
+func TestFilterMenuItems_NilPodWithGroups(t *testing.T) {
+	cfg := Config{MenuItems: []MenuItem{
+		{Title: "Grouped", URL: "http://a", Filters: ItemFilters{AnyOf: []ItemFilters{
+			{Conditions: []Condition{{Path: "metadata.labels", KeyPattern: "app"}}},
 		}}},
-		{Title: "Always", URL: "http://c"},
+		{Title: "No filter", URL: "http://b"},
+	}}
+	if err := ValidateConfig(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	result := FilterMenuItems(cfg.MenuItems, nil)
+	if len(result) != 1 || result[0].Title != "No filter" {
+		t.Errorf("nil pod: got %d items, want 1 (No filter)", len(result))
+	}
+}
+
+// ---- Config vars ----
+
+func TestValidateConfig_Vars(t *testing.T) {
+	t.Setenv("DD_ORG", "org-42")
+	data := `{
+  "vars": {
+    "dd": "https://app.datadoghq.com",
+    "dashboards": "${dd}/dashboard",
+    "org": "${env:DD_ORG}",
+    "team": "payments"
+  },
+  "profiles": [{"name": "prod", "urls": {"dash": "${dashboards}/prd-1"}}],
+  "menuItems": [{
+    "id": "dash",
+    "title": "Datadog (${team})",
+    "description": "Dashboard for ${team} in ${org}",
+    "url": "${dashboards}/abc-123",
+    "templateVars": [{"path": "metadata.name", "urlAppend": "?org=${org}&pod=$VALUE"}]
+  }]
+}`
+	var cfg Config
+	if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatal(err)
 	}
-	cfg := Config{MenuItems: items}
 	if err := ValidateConfig(&cfg); err != nil {
 		t.Fatal(err)
 	}
+	item := cfg.MenuItems[0]
+	if item.Title != "Datadog (payments)" || item.Description != "Dashboard for payments in org-42" {
+		t.Errorf("title, description = %q, %q", item.Title, item.Description)
+	}
+	res := podFromJSON(t, podNginxProd)
+	if got, want := item.ResolveURL(res), "https://app.datadoghq.com/dashboard/abc-123?org=org-42&pod=nginx-abc123"; got != want {
+		t.Errorf("ResolveURL() = %q, want %q", got, want)
+	}
+	if got, want := cfg.Profiles[0].URLs["dash"], "https://app.datadoghq.com/dashboard/prd-1"; got != want {
+		t.Errorf("profile url = %q, want %q", got, want)
+	}
+}
 
-	result := FilterMenuItems(cfg.MenuItems, pd)
-	if len(result) != 2 {
-		t.Fatalf("got %d items, want 2", len(result))
+func TestValidateConfig_VarErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		vars string
+		url  string
+		want string
+	}{
+		{"undefined in url", `{}`, "${dd}/x", "menuItems[0] (a) url: undefined var ${dd}"},
+		{"undefined in var", `{"a": "${b}"}`, "https://a", "vars.a: undefined var ${b}"},
+		{"cycle", `{"a": "${b}/x", "b": "${c}", "c": "${a}"}`, "https://a", "vars.a: cycle a -> b -> c -> a"},
+		{"self reference", `{"a": "${a}"}`, "https://a", "vars.a: cycle a -> a"},
+		{"unset env", `{"a": "${env:GTD_SURELY_UNSET}"}`, "https://a", "vars.a: environment variable GTD_SURELY_UNSET is not set"},
+		{"malformed", `{"a": "x"}`, "${a", `url: malformed var reference in "${a"`},
+		{"bad name", `{"a-b": "x"}`, "https://a", `vars: invalid name "a-b"`},
 	}
-	if result[0].Title != "Matches" || result[1].Title != "Always" {
-		t.Errorf("got [%s, %s], want [Matches, Always]", result[0].Title, result[1].Title)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			data := `{"vars": ` + tt.vars + `, "menuItems": [{"title": "a", "url": "` + tt.url + `"}]}`
+			if err := json.Unmarshal([]byte(data), &cfg); err != nil {
+				t.Fatal(err)
+			}
+			err := ValidateConfig(&cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ValidateConfig() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
 	}
 }
 
-// ---- ResolveURL tests ----
+func TestLintConfig_UnsetEnv(t *testing.T) {
+	// The environment is the one the config runs with, not lint's.
+	data := `{
+  "vars": {"org": "${env:GTD_SURELY_UNSET}", "dd": "https://app.datadoghq.com"},
+  "profiles": [{"name": "prod", "urls": {"dd": "${dd}/apm?org=${env:GTD_SURELY_UNSET_TOO}"}}],
+  "menuItems": [{"id": "dd", "title": "DD", "url": "${dd}/apm?org=${org}&user=${env:GTD_SURELY_UNSET_TOO}"}]
+}`
+	if problems := LintConfig([]byte(data)); len(problems) > 0 {
+		t.Errorf("LintConfig() = %v, want no problems", problems)
+	}
+	// The syntax is still checked.
+	data = `{"vars": {"org": "${env:GTD-ORG}"}, "menuItems": [{"title": "DD", "url": "https://a/${org}"}]}`
+	if problems := LintConfig([]byte(data)); len(problems) != 1 || !strings.Contains(problems[0].Message, "malformed var reference") {
+		t.Errorf("LintConfig() = %v, want a malformed reference", problems)
+	}
+}
 
-func TestResolveURL_LabelPath(t *testing.T) {
-	pd := podFromJSON(t, podNginxProd)
+func TestLoadConfig_VarsFromInclude(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"team.json":   `{"vars": {"dd": "https://app.datadoghq.com", "env": "staging"}, "menuItems": [{"title": "DD ${env}", "url": "${dd}/apm"}]}`,
+		"config.json": `{"include": ["team.json"], "vars": {"env": "prod"}, "menuItems": [{"title": "Mine", "url": "${dd}/logs"}]}`,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg, err := LoadConfig(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := cfg.MenuItems[0].Title + " " + cfg.MenuItems[0].URL + ", " + cfg.MenuItems[1].URL
+	if want := "DD prod https://app.datadoghq.com/apm, https://app.datadoghq.com/logs"; got != want {
+		t.Errorf("items = %q, want %q", got, want)
+	}
 
-	item := MenuItem{
-		Title: "test", URL: "https://example.com/dashboard",
-		TemplateVars: []TemplateVar{
-			{Path: "metadata.labels.app", URLAppend: "?app=$VALUE"},
-		},
+	// Linted on its own, config.json can't know the vars from team.json.
+	data, _ := os.ReadFile(filepath.Join(dir, "config.json"))
+	if problems := LintConfig(data); len(problems) > 0 {
+		t.Errorf("LintConfig(config.json) = %v, want no problems", problems)
 	}
-	cfg := Config{MenuItems: []MenuItem{item}}
+}
+
+// ---- Remote configs ----
+
+// configServer serves config files over TLS with ETags and counts the 304
+// responses.
+type configServer struct {
+	*httptest.Server
+	mu          sync.Mutex
+	files       map[string]string
+	notModified int
+}
+
+func newConfigServer(t *testing.T, files map[string]string) *configServer {
+	t.Helper()
+	s := &configServer{files: files}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		body, ok := s.files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		etag := fmt.Sprintf(`"%x"`, sha256.Sum256([]byte(body)))
+		if r.Header.Get("If-None-Match") == etag {
+			s.notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(s.Close)
+
+	origClient, origWarn := httpClient, warnOutput
+	httpClient = s.Client()
+	t.Cleanup(func() { httpClient, warnOutput = origClient, origWarn })
+	return s
+}
+
+func (s *configServer) set(path, body string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.files[path] = body
+}
+
+func TestLoadConfig_Remote(t *testing.T) {
+	srv := newConfigServer(t, map[string]string{
+		"/k9s/catalogue.yaml": "include: [common.json]\nmenuItems:\n  - {title: Grafana, url: 'https://grafana'}\n",
+		"/k9s/common.json":    `{"menuItems": [{"title": "Runbook", "url": "https://runbook"}]}`,
+	})
+	var warnings bytes.Buffer
+	warnOutput = &warnings
+	src := srv.URL + "/k9s/catalogue.yaml"
+
+	cfg, err := LoadConfig(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := itemTitles(cfg.MenuItems), []string{"Runbook", "Grafana"}; !slices.Equal(got, want) {
+		t.Errorf("titles = %q", got)
+	}
+
+	// Unchanged: both files are revalidated with their ETags.
+	if cfg, err = LoadConfig(src); err != nil || !slices.Equal(itemTitles(cfg.MenuItems), []string{"Runbook", "Grafana"}) {
+		t.Errorf("second load = %q, %v", itemTitles(cfg.MenuItems), err)
+	}
+	if srv.notModified != 2 {
+		t.Errorf("got %d 304 responses, want 2", srv.notModified)
+	}
+
+	srv.set("/k9s/common.json", `{"menuItems": [{"title": "Runbook v2", "url": "https://runbook"}]}`)
+	if cfg, err = LoadConfig(src); err != nil || !slices.Equal(itemTitles(cfg.MenuItems), []string{"Runbook v2", "Grafana"}) {
+		t.Errorf("after change = %q, %v", itemTitles(cfg.MenuItems), err)
+	}
+
+	// Offline: the last good copies are used, with a warning.
+	srv.Close()
+	if cfg, err = LoadConfig(src); err != nil || !slices.Equal(itemTitles(cfg.MenuItems), []string{"Runbook v2", "Grafana"}) {
+		t.Errorf("offline = %q, %v", itemTitles(cfg.MenuItems), err)
+	}
+	if !strings.Contains(warnings.String(), "using the copy cached") {
+		t.Errorf("warnings = %q, want a note about the cached copy", warnings.String())
+	}
+}
+
+func TestLoadConfig_RemoteBrokenPublish(t *testing.T) {
+	srv := newConfigServer(t, map[string]string{
+		"/k9s/config.json": `{"menuItems": [{"title": "Grafana", "url": "https://grafana"}]}`,
+	})
+	var warnings bytes.Buffer
+	warnOutput = &warnings
+	src := srv.URL + "/k9s/config.json"
+
+	if cfg, err := LoadConfig(src); err != nil || !slices.Equal(itemTitles(cfg.MenuItems), []string{"Grafana"}) {
+		t.Fatalf("first load = %q, %v", itemTitles(cfg.MenuItems), err)
+	}
+	for _, broken := range []string{
+		`{"menuItems": [{"title": "Grafana v2", "url"`,        // truncated
+		`{"menuItems": [{"title": "Grafana v2", "url": ""}]}`, // doesn't validate
+	} {
+		srv.set("/k9s/config.json", broken)
+		warnings.Reset()
+		if cfg, err := LoadConfig(src); err != nil || !slices.Equal(itemTitles(cfg.MenuItems), []string{"Grafana"}) {
+			t.Errorf("broken publish = %q, %v, want the cached config", itemTitles(cfg.MenuItems), err)
+		}
+		if !strings.Contains(warnings.String(), "using the copy cached") {
+			t.Errorf("warnings = %q, want a note about the cached copy", warnings.String())
+		}
+	}
+
+	// The broken configs didn't replace the last good copy.
+	srv.Close()
+	if cfg, err := LoadConfig(src); err != nil || !slices.Equal(itemTitles(cfg.MenuItems), []string{"Grafana"}) {
+		t.Errorf("offline = %q, %v", itemTitles(cfg.MenuItems), err)
+	}
+}
+
+func TestLoadConfig_RemoteLayers(t *testing.T) {
+	srv := newConfigServer(t, map[string]string{
+		"/vars.json":  `{"vars": {"org": "42"}}`,
+		"/items.json": `{"menuItems": [{"title": "Prod", "url": "https://grafana/${org}", "use": ["isProd"]}]}`,
+	})
+	var warnings bytes.Buffer
+	warnOutput = &warnings
+	configPath := filepath.Join(t.TempDir(), "config.json")
+	local := fmt.Sprintf(`{
+  "include": [%q, %q],
+  "conditionSets": {"isProd": [{"path": "metadata.labels.env", "valuePattern": "prod"}]}
+}`, srv.URL+"/vars.json", srv.URL+"/items.json")
+	if err := os.WriteFile(configPath, []byte(local), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// Neither layer validates on its own, but both are good layers.
+	cfg, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cfg.MenuItems) != 1 || cfg.MenuItems[0].URL != "https://grafana/42" {
+		t.Errorf("items = %+v", cfg.MenuItems)
+	}
+	if warnings.Len() > 0 {
+		t.Errorf("warnings = %q, want none", warnings.String())
+	}
+
+	// They were cached, so they load offline too.
+	srv.Close()
+	if cfg, err = LoadConfig(configPath); err != nil || len(cfg.MenuItems) != 1 {
+		t.Errorf("offline = %+v, %v", cfg.MenuItems, err)
+	}
+}
+
+func TestLoadConfig_RemoteErrors(t *testing.T) {
+	srv := newConfigServer(t, map[string]string{
+		"/local.json": `{"include": ["file:///etc/passwd"]}`,
+		"/glob.json":  `{"include": ["*.json"]}`,
+	})
+	tests := []struct {
+		name, src, want string
+	}{
+		{"not found, no cache", srv.URL + "/missing.json", "404 Not Found"},
+		{"plain http", "http://example.com/config.json", `unsupported URL scheme "http"`},
+		{"includes a local file", srv.URL + "/local.json", "a https config can't include file URLs"},
+		{"includes a glob", srv.URL + "/glob.json", "globs and ~/ can't be used"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(tt.src)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadConfig() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadConfig_RemoteSignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sign := func(data string) string {
+		return base64.StdEncoding.EncodeToString(ed25519.Sign(priv, []byte(data)))
+	}
+	good := `{"menuItems": [{"title": "Signed", "url": "https://signed"}]}`
+	evil := `{"menuItems": [{"title": "Evil", "url": "https://evil"}]}`
+	srv := newConfigServer(t, map[string]string{
+		"/config.json":     good,
+		"/config.json.sig": sign(good),
+		"/unsigned.json":   good,
+	})
+	var warnings bytes.Buffer
+	warnOutput = &warnings
+	t.Setenv(configKeyEnv, base64.StdEncoding.EncodeToString(pub))
+
+	cfg, err := LoadConfig(srv.URL + "/config.json")
+	if err != nil || cfg.MenuItems[0].Title != "Signed" {
+		t.Fatalf("LoadConfig() = %v, %v", cfg.MenuItems, err)
+	}
+
+	// A tampered config is rejected and the last good copy used instead.
+	srv.set("/config.json", evil)
+	if cfg, err = LoadConfig(srv.URL + "/config.json"); err != nil || cfg.MenuItems[0].Title != "Signed" {
+		t.Errorf("tampered: LoadConfig() = %v, %v, want the cached signed config", cfg.MenuItems, err)
+	}
+	if !strings.Contains(warnings.String(), "signature does not match") {
+		t.Errorf("warnings = %q", warnings.String())
+	}
+
+	if _, err := LoadConfig(srv.URL + "/unsigned.json"); err == nil || !strings.Contains(err.Error(), "signature") {
+		t.Errorf("unsigned: error = %v, want a signature error", err)
+	}
+
+	// file:// configs are checked too, here with a DER key.
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(configKeyEnv, base64.StdEncoding.EncodeToString(der))
+	path := writeConfig(t, "config.json", good)
+	if err := os.WriteFile(path+".sig", []byte(sign(good)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig("file://" + path); err != nil {
+		t.Errorf("file URL: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(evil), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig("file://" + path); err == nil || !strings.Contains(err.Error(), "signature does not match") {
+		t.Errorf("tampered file URL: error = %v", err)
+	}
+}
+
+// ---- Condition and templateVar sets ----
+
+const setsConfig = `{
+  "conditionSets": {
+    "isProd": [{"path": "metadata.labels", "keyPattern": "env", "valuePattern": "production"}],
+    "hasApp": [{"path": "metadata.labels", "keyPattern": "app"}],
+    "podQuery": [{"path": "kind", "valuePattern": "Pod"}]
+  },
+  "templateVarSets": {
+    "podQuery": [
+      {"path": "metadata.namespace", "urlAppend": "?ns=$VALUE"},
+      {"path": "metadata.name", "urlAppend": "&pod=$VALUE"}
+    ]
+  },
+  "menuItems": [
+    {
+      "title": "Prod logs",
+      "url": "https://logs.example.com/search",
+      "use": ["isProd", "podQuery"],
+      "filters": {"conditions": [{"path": "status.phase", "valuePattern": "Running"}]},
+      "templateVars": [{"path": "metadata.labels.app", "urlAppend": "&app=$VALUE"}]
+    },
+    {
+      "title": "App",
+      "url": "https://apps.example.com/",
+      "use": ["hasApp"],
+      "templateVars": [{"path": "metadata.labels.app", "urlAppend": "$VALUE"}]
+    }
+  ]
+}`
+
+func TestValidateConfig_Use(t *testing.T) {
+	var cfg Config
+	if err := json.Unmarshal([]byte(setsConfig), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateConfig(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	// Validating again must not add the sets twice.
 	if err := ValidateConfig(&cfg); err != nil {
 		t.Fatal(err)
 	}
+	logs, app := cfg.MenuItems[0], cfg.MenuItems[1]
+	if n, m := len(logs.Filters.Conditions), len(logs.TemplateVars); n != 3 || m != 3 {
+		t.Fatalf("logs has %d conditions and %d templateVars, want 3 and 3", n, m)
+	}
 
-	got := cfg.MenuItems[0].ResolveURL(pd)
-	want := "https://example.com/dashboard?app=nginx"
-	if got != want {
-		t.Errorf("ResolveURL = %q, want %q", got, want)
+	prod := podFromJSON(t, podNginxProd)
+	staging := podFromJSON(t, podRedisStaging)
+	if !logs.Matches(prod) || logs.Matches(staging) {
+		t.Errorf("logs.Matches() = %v (prod), %v (staging), want true, false", logs.Matches(prod), logs.Matches(staging))
+	}
+	if got, want := logs.ResolveURL(prod), "https://logs.example.com/search?ns=production&pod=nginx-abc123&app=nginx"; got != want {
+		t.Errorf("logs.ResolveURL() = %q, want %q", got, want)
+	}
+	if !app.Matches(staging) || app.Matches(podFromJSON(t, podNoLabels)) {
+		t.Errorf("app.Matches() is wrong")
 	}
 }
 
-func TestResolveURL_ScalarPath(t *testing.T) {
-	pd := podFromJSON(t, podNginxProd)
+func TestValidateConfig_UseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  string
+		want string
+	}{
+		{"unknown set", `{"menuItems": [{"title": "a", "url": "https://a", "use": ["nope"]}]}`,
+			`menuItems[0] (a) use: no conditionSet or templateVarSet "nope"`},
+		{"invalid condition in set", `{"conditionSets": {"bad": [{"path": "x", "valuePattern": "[x"}]}, "menuItems": [{"title": "a", "url": "https://a", "use": ["bad"]}]}`,
+			"menuItems[0] (a) conditions[0] invalid valuePattern"},
+		{"templateVars with urlTemplate", `{"templateVarSets": {"q": [{"path": "x", "urlAppend": "?x=$VALUE"}]}, "menuItems": [{"title": "a", "urlTemplate": "https://a", "use": ["q"]}]}`,
+			"cannot use both urlTemplate and templateVars"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			if err := json.Unmarshal([]byte(tt.cfg), &cfg); err != nil {
+				t.Fatal(err)
+			}
+			err := ValidateConfig(&cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ValidateConfig() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintConfig_Sets(t *testing.T) {
+	data := `{
+  "conditionSets": {
+    "isProd": [{"path": "metadata.labels", "keyPattern": "^env$", "valuePattern": "production"}]
+  },
+  "templateVarSets": {
+    "pod": [{"path": "metadata.name", "urlAppend": "?pod=static"}]
+  },
+  "menuItems": [
+    {"title": "A", "url": "https://a", "use": ["isProd", "pod"]},
+    {"title": "B", "url": "https://b", "use": ["isProd", "missing"]}
+  ]
+}`
+	var got []string
+	for _, p := range LintConfig([]byte(data)) {
+		got = append(got, p.String())
+	}
+	want := []string{
+		`3:44: warning: keyPattern "^env$" is anchored twice; patterns are implicitly anchored with ^...$`,
+		`6:39: warning: urlAppend "?pod=static" doesn't contain $VALUE, so the value of metadata.name is never inserted`,
+		`10:40: error: menuItems[1] (B) use: no conditionSet or templateVarSet "missing"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("LintConfig() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLintConfig_SetUnknownFields(t *testing.T) {
+	data := `{
+  "conditionSets": {
+    "is/prod": [{"path": "metadata.labels", "keyPatern": "env", "valuePattern": "production"}]
+  },
+  "templateVarSets": {
+    "pod": [{"path": "metadata.name", "urlApend": "?pod=$VALUE"}]
+  },
+  "menuItems": [{"title": "A", "url": "https://a", "use": ["is/prod"]}]
+}`
+	var got []string
+	for _, p := range LintConfig([]byte(data)) {
+		got = append(got, p.Pointer+" "+p.String())
+	}
+	want := []string{
+		`/conditionSets/is~1prod/0/keyPatern 3:45: error: unknown field "keyPatern" (did you mean "keyPattern"?)`,
+		`/templateVarSets/pod/0/urlApend 6:39: error: unknown field "urlApend" (did you mean "urlAppend"?)`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("LintConfig() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// ---- Profiles ----
+
+const profilesConfig = `{
+  "profiles": [
+    {"name": "defaults", "vars": {"ENV": "dev", "DASH": "abc-123"}},
+    {"name": "staging", "context": "staging-.*", "vars": {"ENV": "staging"}},
+    {"name": "prod", "cluster": "prod-(eu|us)", "vars": {"ENV": "prod"},
+     "urls": {"datadog": "https://app.datadoghq.com/dashboard/prd-999"}}
+  ],
+  "menuItems": [
+    {
+      "id": "datadog",
+      "title": "Datadog",
+      "url": "https://app.datadoghq.com/dashboard/stg-111",
+      "templateVars": [
+        {"value": "$ENV", "urlAppend": "?tpl_var_env=$VALUE"},
+        {"path": "metadata.name", "urlAppend": "&tpl_var_pod=$VALUE&cluster=$CLUSTER"}
+      ]
+    },
+    {
+      "title": "Logs",
+      "urlTemplate": "https://logs.example.com/{{var \"CONTEXT\"}}/{{var \"DASH\"}}?q={{.metadata.name}}"
+    }
+  ]
+}`
+
+func TestApplyProfiles(t *testing.T) {
+	tests := []struct {
+		context, cluster string
+		profiles         string
+		datadog, logs    string
+	}{
+		{"staging-eu", "stg-eu", "defaults, staging",
+			"https://app.datadoghq.com/dashboard/stg-111?tpl_var_env=staging&tpl_var_pod=nginx-abc123&cluster=stg-eu",
+			"https://logs.example.com/staging-eu/abc-123?q=nginx-abc123"},
+		{"admin@prod", "prod-eu", "defaults, prod",
+			"https://app.datadoghq.com/dashboard/prd-999?tpl_var_env=prod&tpl_var_pod=nginx-abc123&cluster=prod-eu",
+			"https://logs.example.com/admin@prod/abc-123?q=nginx-abc123"},
+		{"kind-local", "kind", "defaults",
+			"https://app.datadoghq.com/dashboard/stg-111?tpl_var_env=dev&tpl_var_pod=nginx-abc123&cluster=kind",
+			"https://logs.example.com/kind-local/abc-123?q=nginx-abc123"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.context, func(t *testing.T) {
+			var cfg Config
+			if err := json.Unmarshal([]byte(profilesConfig), &cfg); err != nil {
+				t.Fatal(err)
+			}
+			if err := ValidateConfig(&cfg); err != nil {
+				t.Fatal(err)
+			}
+			vars, names := cfg.ApplyProfiles(tt.context, tt.cluster)
+			if got := strings.Join(names, ", "); got != tt.profiles {
+				t.Errorf("profiles = %q, want %q", got, tt.profiles)
+			}
+			res := podFromJSON(t, podNginxProd)
+			res.Vars = vars
+			if got := cfg.MenuItems[0].ResolveURL(res); got != tt.datadog {
+				t.Errorf("datadog url = %q, want %q", got, tt.datadog)
+			}
+			if got := cfg.MenuItems[1].ResolveURL(res); got != tt.logs {
+				t.Errorf("logs url = %q, want %q", got, tt.logs)
+			}
+		})
+	}
+}
 
+func TestResolveURL_UnsetGlobalPlaceholder(t *testing.T) {
 	item := MenuItem{
-		Title: "test", URL: "https://example.com/nodes",
+		Title: "x",
+		URL:   "https://example.com/",
 		TemplateVars: []TemplateVar{
-			{Path: "spec.nodeName", URLAppend: "?node=$VALUE"},
+			{Path: "metadata.name", URLAppend: "$VALUE"},
+			{Path: "metadata.namespace", URLAppend: "?cluster=$CLUSTER"},
 		},
 	}
 	cfg := Config{MenuItems: []MenuItem{item}}
 	if err := ValidateConfig(&cfg); err != nil {
 		t.Fatal(err)
 	}
+	res := podFromJSON(t, podNginxProd)
+	if got, want := cfg.MenuItems[0].ResolveURL(res), "https://example.com/nginx-abc123"; got != want {
+		t.Errorf("ResolveURL() = %q, want %q", got, want)
+	}
+}
 
-	got := cfg.MenuItems[0].ResolveURL(pd)
+func TestValidateConfig_Profiles(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  string
+		want string
+	}{
+		{"no name", `{"profiles": [{"context": "x"}], "menuItems": [{"title": "a", "url": "https://a"}]}`, "profiles[0] has empty name"},
+		{"bad regex", `{"profiles": [{"name": "p", "cluster": "[x"}], "menuItems": [{"title": "a", "url": "https://a"}]}`, `profiles[0] (p) invalid cluster "[x"`},
+		{"bad var name", `{"profiles": [{"name": "p", "vars": {"env": "x"}}], "menuItems": [{"title": "a", "url": "https://a"}]}`, `invalid var name "env"`},
+		{"builtin var", `{"profiles": [{"name": "p", "vars": {"CLUSTER": "x"}}], "menuItems": [{"title": "a", "url": "https://a"}]}`, "var CLUSTER is set from the kube context"},
+		{"undefined in value", `{"menuItems": [{"title": "a", "url": "https://a", "templateVars": [{"value": "$ENV", "urlAppend": "$VALUE"}]}]}`, "value references undefined placeholder $ENV"},
+		{"undefined in urlAppend", `{"menuItems": [{"title": "a", "url": "https://a", "templateVars": [{"path": "x", "urlAppend": "$ENV"}]}]}`, "urlAppend references undefined placeholder $ENV"},
+		{"shadows global", `{"menuItems": [{"title": "a", "url": "https://a", "templateVars": [{"path": "x", "placeholder": "$CONTEXT"}]}]}`, "duplicate placeholder $CONTEXT"},
+		{"url for unknown id", `{"profiles": [{"name": "p", "urls": {"nope": "https://b"}}], "menuItems": [{"id": "a", "title": "a", "url": "https://a"}]}`, `profiles[0] (p) urls.nope: no menu item has id "nope"`},
+		{"url for urlTemplate item", `{"profiles": [{"name": "p", "urls": {"a": "https://b"}}], "menuItems": [{"id": "a", "title": "a", "url": "https://a", "urlTemplate": "https://a/{{.metadata.name}}"}]}`, `profiles[0] (p) urls.a: menu item "a" uses urlTemplate`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var cfg Config
+			if err := json.Unmarshal([]byte(tt.cfg), &cfg); err != nil {
+				t.Fatal(err)
+			}
+			err := ValidateConfig(&cfg)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ValidateConfig() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintConfig_ProfileURLs(t *testing.T) {
+	data := `{
+  "profiles": [{"name": "prod", "urls": {"logs": "https://b", "typo": "https://c"}}],
+  "menuItems": [{"id": "logs", "title": "Logs", "urlTemplate": "https://a/{{.metadata.name}}"}]
+}`
+	var got []string
+	for _, p := range LintConfig([]byte(data)) {
+		got = append(got, p.String())
+	}
+	want := []string{
+		`2:42: error: profiles[0] (prod) urls.logs: menu item "logs" uses urlTemplate, which a url override doesn't replace`,
+		`2:63: error: profiles[0] (prod) urls.typo: no menu item has id "typo"`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("LintConfig() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	// The item may come from an include
+	if problems := LintConfig([]byte(`{"include": ["team.json"], "profiles": [{"name": "prod", "urls": {"typo": "https://c"}}]}`)); len(problems) > 0 {
+		t.Errorf("LintConfig() with include = %v, want no problems", problems)
+	}
+}
+
+// ---- Filter expressions ----
+
+func TestItemFilters_Expression(t *testing.T) {
+	nginx := podFromJSON(t, podNginxProd)
+	redis := podFromJSON(t, podRedisStaging)
+	restarted := podFromJSON(t, podWithResources)
+	bare := podFromJSON(t, podNoLabels)
+
+	tests := []struct {
+		name string
+		expr string
+		want map[*Resource]bool
+	}{
+		{"label shortcut", `labels.app == "nginx"`, map[*Resource]bool{nginx: true, redis: false, bare: false}},
+		{"full path", `metadata.labels.env in ["prod", "production"]`, map[*Resource]bool{nginx: true, redis: false, bare: false}},
+		{"any over a list", `any(status.containerStatuses, .restartCount > 3)`, map[*Resource]bool{restarted: true, nginx: false, bare: false}},
+		{"combined", `labels.tier == "backend" && any(spec.containers, .resources.limits.cpu == "500m")`, map[*Resource]bool{restarted: true, nginx: false}},
+		{"missing field", `spec.nodeName startsWith "prod-"`, map[*Resource]bool{bare: false}},
+		{"negation of missing label", `!("app" in labels)`, map[*Resource]bool{bare: true, nginx: false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{MenuItems: []MenuItem{{Title: "test", URL: "http://test", Filters: ItemFilters{Expression: tt.expr}}}}
+			if err := ValidateConfig(&cfg); err != nil {
+				t.Fatal(err)
+			}
+			for r, want := range tt.want {
+				if got := cfg.MenuItems[0].Matches(r); got != want {
+					t.Errorf("Matches(%s) = %v, want %v", r.Name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestItemFilters_ExpressionWithConditions(t *testing.T) {
+	raw := `{"menuItems": [{
+		"title": "Crash investigation",
+		"url": "http://test",
+		"filters": {
+			"conditions": [{"path": "metadata.labels", "keyPattern": "tier"}],
+			"anyOf": [
+				{"expression": "any(status.containerStatuses, .restartCount > 3)"},
+				{"expression": "spec.nodeName == 'prod-node-1'"}
+			]
+		}
+	}]}`
+	var cfg Config
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateConfig(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.MenuItems[0].Matches(podFromJSON(t, podWithResources)) {
+		t.Error("expected restarted backend pod to match")
+	}
+	if cfg.MenuItems[0].Matches(podFromJSON(t, podNginxProd)) {
+		t.Error("expected pod without tier label not to match")
+	}
+}
+
+func TestValidateConfig_Expression(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+		want string // substring of the error
+	}{
+		{"syntax error", `labels.app == `, "(1:14)"},
+		{"position on second line", "labels.app == \"nginx\" &&\n  spec.nodeName ==== \"x\"", "(2:"},
+		{"not boolean", `len(labels) + 1`, "expected bool"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{MenuItems: []MenuItem{{Title: "test", URL: "http://test", Filters: ItemFilters{
+				AnyOf: []ItemFilters{{Conditions: []Condition{{Path: "metadata.name"}}}, {Expression: tt.expr}},
+			}}}}
+			err := ValidateConfig(&cfg)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), "anyOf[1] invalid expression") || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %q, want position and %q", err, tt.want)
+			}
+		})
+	}
+}
+
+// ---- FilterMenuItems tests ----
+
+func TestFilterMenuItems_NilPod(t *testing.T) {
+	items := []MenuItem{
+		{Title: "With filter", URL: "http://a", Filters: ItemFilters{Conditions: []Condition{
+			{Path: "metadata.labels", KeyPattern: "app"},
+		}}},
+		{Title: "No filter", URL: "http://b"},
+	}
+	cfg := Config{MenuItems: items}
+	if err := ValidateConfig(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	result := FilterMenuItems(cfg.MenuItems, nil)
+	if len(result) != 1 || result[0].Title != "No filter" {
+		t.Errorf("nil pod: got %d items, want 1 (No filter)", len(result))
+	}
+}
+
+func TestFilterMenuItems_WithPod(t *testing.T) {
+	pd := podFromJSON(t, podNginxProd)
+
+	items := []MenuItem{
+		{Title: "Matches", URL: "http://a", Filters: ItemFilters{Conditions: []Condition{
+			{Path: "metadata.labels", KeyPattern: "app", ValuePattern: "nginx"},
+		}}},
+		{Title: "No match", URL: "http://b", Filters: ItemFilters{Conditions: []Condition{
+			{Path: "metadata.labels", KeyPattern: "app", ValuePattern: "redis"},
+		}}},
+		{Title: "Always", URL: "http://c"},
+	}
+	cfg := Config{MenuItems: items}
+	if err := ValidateConfig(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	result := FilterMenuItems(cfg.MenuItems, pd)
+	if len(result) != 2 {
+		t.Fatalf("got %d items, want 2", len(result))
+	}
+	if result[0].Title != "Matches" || result[1].Title != "Always" {
+		t.Errorf("got [%s, %s], want [Matches, Always]", result[0].Title, result[1].Title)
+	}
+}
+
+// ---- Resource kinds ----
+
+const deploymentNginx = `{
+  "apiVersion": "apps/v1",
+  "kind": "Deployment",
+  "metadata": {
+    "name": "nginx",
+    "namespace": "production",
+    "labels": {
+      "app": "nginx"
+    }
+  },
+  "spec": {
+    "replicas": 3
+  }
+}`
+
+const nodeProd = `{
+  "apiVersion": "v1",
+  "kind": "Node",
+  "metadata": {
+    "name": "prod-pool-node-01",
+    "labels": {
+      "node.kubernetes.io/instance-type": "m5.large"
+    }
+  }
+}`
+
+func TestNormalizeKind(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"pod", "pod"},
+		{"pods", "pod"},
+		{"Pods", "pod"},
+		{"deploy", "deployment"},
+		{"deployments", "deployment"},
+		{"sts", "statefulset"},
+		{"nodes", "node"},
+		{"svc", "service"},
+		{"jobs", "job"},
+		{"ing", "ingress"},
+		{"ingresses", "ingress"},
+		{"cm", "configmap"},
+		{"pvc", "persistentvolumeclaim"},
+		{"sa", "serviceaccount"},
+		{"hpa", "horizontalpodautoscaler"},
+		{"cj", "cronjob"},
+		{"endpoints", "endpoints"},
+		{"certificates.cert-manager.io", "certificates.cert-manager.io"},
+	}
+	for _, tt := range tests {
+		if got := NormalizeKind(tt.input); got != tt.want {
+			t.Errorf("NormalizeKind(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestKindAliases(t *testing.T) {
+	for _, k := range builtinKinds {
+		for _, alias := range append([]string{k.kind, k.plural}, k.short...) {
+			if got := NormalizeKind(alias); got != k.kind {
+				t.Errorf("NormalizeKind(%q) = %q, want %q", alias, got, k.kind)
+			}
+		}
+	}
+}
+
+func TestNewResource(t *testing.T) {
+	if r := NewResource("pod", "", "default"); r != nil {
+		t.Errorf("NewResource with empty name = %+v, want nil", r)
+	}
+	if r := NewResource("", "nginx", "default"); r.Kind != "pod" {
+		t.Errorf("NewResource default kind = %q, want pod", r.Kind)
+	}
+	if r := NewResource("deployments", "nginx", "default"); r.Kind != "deployment" {
+		t.Errorf("NewResource kind = %q, want deployment", r.Kind)
+	}
+}
+
+func TestFilterMenuItems_Kinds(t *testing.T) {
+	cfg := Config{MenuItems: []MenuItem{
+		{Title: "Pod logs", URL: "http://a", Kinds: []string{"pods"}},
+		{Title: "Workload", URL: "http://b", Kinds: []string{"deploy", "sts"}, Filters: ItemFilters{Conditions: []Condition{
+			{Path: "metadata.labels", KeyPattern: "app"},
+		}}},
+		{Title: "Node metrics", URL: "http://c", Kinds: []string{"node"}},
+		{Title: "Any kind", URL: "http://d"},
+	}}
+	if err := ValidateConfig(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.MenuItems[1].Kinds; got[0] != "deployment" || got[1] != "statefulset" {
+		t.Errorf("kinds not normalized: %v", got)
+	}
+
+	tests := []struct {
+		name string
+		res  *Resource
+		want []string
+	}{
+		{"pod", podFromJSON(t, podNginxProd), []string{"Pod logs", "Any kind"}},
+		{"deployment", resourceFromJSON(t, "deployment", deploymentNginx), []string{"Workload", "Any kind"}},
+		{"node", resourceFromJSON(t, "node", nodeProd), []string{"Node metrics", "Any kind"}},
+		{"nil resource", nil, []string{"Any kind"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := FilterMenuItems(cfg.MenuItems, tt.res)
+			var titles []string
+			for _, it := range result {
+				titles = append(titles, it.Title)
+			}
+			if strings.Join(titles, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("got %v, want %v", titles, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateConfig_EmptyKind(t *testing.T) {
+	cfg := Config{MenuItems: []MenuItem{{Title: "test", URL: "http://test", Kinds: []string{""}}}}
+	if err := ValidateConfig(&cfg); err == nil {
+		t.Error("expected error for empty kind, got nil")
+	}
+}
+
+// ---- ResolveURL tests ----
+
+func TestResolveURL_LabelPath(t *testing.T) {
+	pd := podFromJSON(t, podNginxProd)
+
+	item := MenuItem{
+		Title: "test", URL: "https://example.com/dashboard",
+		TemplateVars: []TemplateVar{
+			{Path: "metadata.labels.app", URLAppend: "?app=$VALUE"},
+		},
+	}
+	cfg := Config{MenuItems: []MenuItem{item}}
+	if err := ValidateConfig(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	got := cfg.MenuItems[0].ResolveURL(pd)
+	want := "https://example.com/dashboard?app=nginx"
+	if got != want {
+		t.Errorf("ResolveURL = %q, want %q", got, want)
+	}
+}
+
+func TestResolveURL_ScalarPath(t *testing.T) {
+	pd := podFromJSON(t, podNginxProd)
+
+	item := MenuItem{
+		Title: "test", URL: "https://example.com/nodes",
+		TemplateVars: []TemplateVar{
+			{Path: "spec.nodeName", URLAppend: "?node=$VALUE"},
+		},
+	}
+	cfg := Config{MenuItems: []MenuItem{item}}
+	if err := ValidateConfig(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	got := cfg.MenuItems[0].ResolveURL(pd)
 	want := "https://example.com/nodes?node=prod-pool-node-01"
 	if got != want {
 		t.Errorf("ResolveURL = %q, want %q", got, want)
 	}
 }
 
-func TestResolveURL_MultipleReplacements(t *testing.T) {
-	pd := podFromJSON(t, podNginxProd)
-
-	item := MenuItem{
-		Title: "test", URL: "https://example.com",
-		TemplateVars: []TemplateVar{
-			{Path: "metadata.labels.app", URLAppend: "?app=$VALUE&extra=$VALUE"},
-		},
-	}
-	cfg := Config{MenuItems: []MenuItem{item}}
+func TestResolveURL_MultipleReplacements(t *testing.T) {
+	pd := podFromJSON(t, podNginxProd)
+
+	item := MenuItem{
+		Title: "test", URL: "https://example.com",
+		TemplateVars: []TemplateVar{
+			{Path: "metadata.labels.app", URLAppend: "?app=$VALUE&extra=$VALUE"},
+		},
+	}
+	cfg := Config{MenuItems: []MenuItem{item}}
+	if err := ValidateConfig(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	got := cfg.MenuItems[0].ResolveURL(pd)
+	want := "https://example.com?app=nginx&extra=nginx"
+	if got != want {
+		t.Errorf("ResolveURL = %q, want %q", got, want)
+	}
+}
+
+func TestResolveURL_MissingKey(t *testing.T) {
+	pd := podFromJSON(t, podNginxProd)
+
+	item := MenuItem{
+		Title: "test", URL: "https://example.com",
+		TemplateVars: []TemplateVar{
+			{Path: "metadata.labels.nonexistent", URLAppend: "?x=$VALUE"},
+		},
+	}
+	cfg := Config{MenuItems: []MenuItem{item}}
+	if err := ValidateConfig(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	got := cfg.MenuItems[0].ResolveURL(pd)
+	want := "https://example.com" // nothing appended
+	if got != want {
+		t.Errorf("ResolveURL = %q, want %q", got, want)
+	}
+}
+
+func TestResolveURL_MultipleVars(t *testing.T) {
+	pd := podFromJSON(t, podNginxProd)
+
+	item := MenuItem{
+		Title: "test", URL: "https://example.com",
+		TemplateVars: []TemplateVar{
+			{Path: "metadata.labels.app", URLAppend: "?app=$VALUE"},
+			{Path: "metadata.name", URLAppend: "&pod=$VALUE"},
+		},
+	}
+	cfg := Config{MenuItems: []MenuItem{item}}
+	if err := ValidateConfig(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	got := cfg.MenuItems[0].ResolveURL(pd)
+	want := "https://example.com?app=nginx&pod=nginx-abc123"
+	if got != want {
+		t.Errorf("ResolveURL = %q, want %q", got, want)
+	}
+}
+
+func TestResolveURL_NilPod(t *testing.T) {
+	item := MenuItem{
+		Title: "test", URL: "https://example.com",
+		TemplateVars: []TemplateVar{
+			{Path: "metadata.labels.app", URLAppend: "?app=$VALUE"},
+		},
+	}
+	cfg := Config{MenuItems: []MenuItem{item}}
+	if err := ValidateConfig(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	got := cfg.MenuItems[0].ResolveURL(nil)
+	want := "https://example.com"
+	if got != want {
+		t.Errorf("ResolveURL(nil) = %q, want %q", got, want)
+	}
+}
+
+// ---- URL encoding ----
+
+const podHostileLabels = `{
+  "metadata": {
+    "name": "evil-pod",
+    "labels": {
+      "app": "my app&admin=true#frag",
+      "team": "infra/platform",
+      "query": "a%20b",
+      "unicode": "café ☕"
+    }
+  }
+}`
+
+func TestResolveURL_Escaping(t *testing.T) {
+	pd := podFromJSON(t, podHostileLabels)
+
+	tests := []struct {
+		name string
+		url  string
+		vars []TemplateVar
+		want string
+	}{
+		{
+			name: "query value is query-escaped",
+			url:  "https://example.com/dash",
+			vars: []TemplateVar{{Path: "metadata.labels.app", URLAppend: "?app=$VALUE"}},
+			want: "https://example.com/dash?app=my+app%26admin%3Dtrue%23frag",
+		},
+		{
+			name: "path segment is path-escaped",
+			url:  "https://example.com/teams",
+			vars: []TemplateVar{{Path: "metadata.labels.team", URLAppend: "/$VALUE/overview"}},
+			want: "https://example.com/teams/infra%2Fplatform/overview",
+		},
+		{
+			name: "query detected from earlier vars",
+			url:  "https://example.com/teams",
+			vars: []TemplateVar{
+				{Path: "metadata.labels.team", URLAppend: "/$VALUE"},
+				{Path: "metadata.labels.app", URLAppend: "?app=$VALUE"},
+				{Path: "metadata.labels.team", URLAppend: "&team=$VALUE"},
+			},
+			want: "https://example.com/teams/infra%2Fplatform?app=my+app%26admin%3Dtrue%23frag&team=infra%2Fplatform",
+		},
+		{
+			name: "query detected from base url",
+			url:  "https://example.com/?live=true",
+			vars: []TemplateVar{{Path: "metadata.labels.unicode", URLAppend: "&q=$VALUE"}},
+			want: "https://example.com/?live=true&q=caf%C3%A9+%E2%98%95",
+		},
+		{
+			name: "path and query in one urlAppend",
+			url:  "https://example.com",
+			vars: []TemplateVar{{Path: "metadata.labels.team", URLAppend: "/t/$VALUE?team=$VALUE"}},
+			want: "https://example.com/t/infra%2Fplatform?team=infra%2Fplatform",
+		},
+		{
+			name: "fragment route is path-escaped",
+			url:  "https://example.com/?org=1#/teams",
+			vars: []TemplateVar{{Path: "metadata.labels.team", URLAppend: "/$VALUE"}},
+			want: "https://example.com/?org=1#/teams/infra%2Fplatform",
+		},
+		{
+			name: "query inside fragment is query-escaped",
+			url:  "https://example.com/#/logs",
+			vars: []TemplateVar{{Path: "metadata.labels.app", URLAppend: "?app=$VALUE"}},
+			want: "https://example.com/#/logs?app=my+app%26admin%3Dtrue%23frag",
+		},
+		{
+			name: "raw value is inserted as is",
+			url:  "https://example.com",
+			vars: []TemplateVar{{Path: "metadata.labels.query", URLAppend: "?q=$VALUE", Raw: true}},
+			want: "https://example.com?q=a%20b",
+		},
+		{
+			name: "plain values are unchanged",
+			url:  "https://example.com",
+			vars: []TemplateVar{{Path: "metadata.name", URLAppend: "?pod=$VALUE"}},
+			want: "https://example.com?pod=evil-pod",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{MenuItems: []MenuItem{{Title: "test", URL: tt.url, TemplateVars: tt.vars}}}
+			if err := ValidateConfig(&cfg); err != nil {
+				t.Fatal(err)
+			}
+			got := cfg.MenuItems[0].ResolveURL(pd)
+			if got != tt.want {
+				t.Errorf("ResolveURL = %q, want %q", got, tt.want)
+			}
+			if _, err := url.Parse(got); err != nil {
+				t.Errorf("ResolveURL produced an unparseable URL: %v", err)
+			}
+		})
+	}
+}
+
+func TestResolveURL_EscapedQueryRoundTrip(t *testing.T) {
+	pd := podFromJSON(t, podHostileLabels)
+
+	cfg := Config{MenuItems: []MenuItem{{
+		Title: "test", URL: "https://example.com/dash",
+		TemplateVars: []TemplateVar{
+			{Path: "metadata.labels.app", URLAppend: "?app=$VALUE"},
+			{Path: "metadata.labels.team", URLAppend: "&team=$VALUE"},
+		},
+	}}}
+	if err := ValidateConfig(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	u, err := url.Parse(cfg.MenuItems[0].ResolveURL(pd))
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if got := q.Get("app"); got != "my app&admin=true#frag" {
+		t.Errorf("app = %q", got)
+	}
+	if got := q.Get("team"); got != "infra/platform" {
+		t.Errorf("team = %q", got)
+	}
+	if q.Has("admin") || u.Fragment != "" {
+		t.Errorf("value leaked into URL structure: %v", u)
+	}
+}
+
+func TestResolveVars(t *testing.T) {
+	pd := podFromJSON(t, podHostileLabels)
+
+	item := MenuItem{
+		Title: "test", URL: "https://example.com",
+		TemplateVars: []TemplateVar{
+			{Path: "metadata.labels.missing", URLAppend: "?x=$VALUE"},
+			{Path: "metadata.labels.team", URLAppend: "?team=$VALUE"},
+		},
+	}
+	got := item.ResolveVars(pd)
+	if len(got) != 1 {
+		t.Fatalf("got %d resolved vars, want 1", len(got))
+	}
+	want := ResolvedVar{Path: "metadata.labels.team", Value: "infra/platform", Appended: "?team=infra%2Fplatform"}
+	if got[0] != want {
+		t.Errorf("ResolveVars = %+v, want %+v", got[0], want)
+	}
+}
+
+func TestResolveURL_TemplatePathEscape(t *testing.T) {
+	pd := podFromJSON(t, podHostileLabels)
+
+	cfg := Config{MenuItems: []MenuItem{{
+		Title:       "test",
+		URLTemplate: `https://example.com/teams/{{label "team" | pathescape}}?app={{label "app" | urlquery}}`,
+	}}}
+	if err := ValidateConfig(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	want := "https://example.com/teams/infra%2Fplatform?app=my+app%26admin%3Dtrue%23frag"
+	if got := cfg.MenuItems[0].ResolveURL(pd); got != want {
+		t.Errorf("ResolveURL = %q, want %q", got, want)
+	}
+}
+
+// ---- Named placeholders and key extraction ----
+
+func TestResolveURL_NamedPlaceholders(t *testing.T) {
+	pd := podFromJSON(t, podNginxProd)
+
+	tests := []struct {
+		name string
+		vars []TemplateVar
+		want string
+	}{
+		{
+			name: "key extraction from map path",
+			vars: []TemplateVar{
+				{Path: "metadata.labels", Key: "app", Placeholder: "$APP", URLAppend: "?app=$APP"},
+			},
+			want: "https://example.com?app=nginx",
+		},
+		{
+			name: "key with dots",
+			vars: []TemplateVar{
+				{Path: "metadata.annotations", Key: "prometheus.io/port", URLAppend: "?port=$VALUE"},
+			},
+			want: "https://example.com?port=9090",
+		},
+		{
+			name: "one urlAppend referencing several placeholders",
+			vars: []TemplateVar{
+				{Path: "spec.nodeName", Placeholder: "$NODE"},
+				{Path: "metadata.labels", Key: "app", Placeholder: "$APP", URLAppend: "?app=$APP&node=$NODE"},
+			},
+			want: "https://example.com?app=nginx&node=prod-pool-node-01",
+		},
+		{
+			name: "placeholder defined after use",
+			vars: []TemplateVar{
+				{Path: "metadata.labels", Key: "app", Placeholder: "$APP", URLAppend: "?app=$APP&pod=$POD_NAME"},
+				{Path: "metadata.name", Placeholder: "$POD_NAME"},
+			},
+			want: "https://example.com?app=nginx&pod=nginx-abc123",
+		},
+		{
+			name: "placeholders sharing a prefix",
+			vars: []TemplateVar{
+				{Path: "metadata.labels", Key: "app", Placeholder: "$APP"},
+				{Path: "metadata.labels", Key: "env", Placeholder: "$APP_ENV", URLAppend: "?env=$APP_ENV&app=$APP"},
+			},
+			want: "https://example.com?env=production&app=nginx",
+		},
+		{
+			name: "$VALUE still refers to the var's own value",
+			vars: []TemplateVar{
+				{Path: "metadata.labels", Key: "team", Placeholder: "$TEAM", URLAppend: "?team=$VALUE"},
+				{Path: "metadata.labels.app", URLAppend: "&app=$VALUE&team=$TEAM"},
+			},
+			want: "https://example.com?team=platform&app=nginx&team=platform",
+		},
+		{
+			name: "append skipped when a referenced placeholder is missing",
+			vars: []TemplateVar{
+				{Path: "metadata.labels", Key: "version", Placeholder: "$VERSION"},
+				{Path: "metadata.labels", Key: "app", Placeholder: "$APP", URLAppend: "?app=$APP&v=$VERSION"},
+				{Path: "metadata.name", URLAppend: "?pod=$VALUE"},
+			},
+			want: "https://example.com?pod=nginx-abc123",
+		},
+		{
+			name: "key on a non-map path resolves to nothing",
+			vars: []TemplateVar{
+				{Path: "spec.nodeName", Key: "x", URLAppend: "?x=$VALUE"},
+			},
+			want: "https://example.com",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{MenuItems: []MenuItem{{Title: "test", URL: "https://example.com", TemplateVars: tt.vars}}}
+			if err := ValidateConfig(&cfg); err != nil {
+				t.Fatal(err)
+			}
+			if got := cfg.MenuItems[0].ResolveURL(pd); got != tt.want {
+				t.Errorf("ResolveURL = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveVars_DisplayPathWithKey(t *testing.T) {
+	pd := podFromJSON(t, podNginxProd)
+
+	item := MenuItem{Title: "test", URL: "https://example.com", TemplateVars: []TemplateVar{
+		{Path: "metadata.annotations", Key: "prometheus.io/port", URLAppend: "?port=$VALUE"},
+	}}
+	got := item.ResolveVars(pd)
+	if len(got) != 1 || got[0].Path != `metadata.annotations['prometheus.io/port']` {
+		t.Errorf("ResolveVars = %+v", got)
+	}
+}
+
+func TestValidateConfig_Placeholders(t *testing.T) {
+	tests := []struct {
+		name string
+		vars []TemplateVar
+	}{
+		{"undefined placeholder", []TemplateVar{
+			{Path: "metadata.name", URLAppend: "?pod=$VALUE&node=$NODE"},
+		}},
+		{"duplicate placeholder", []TemplateVar{
+			{Path: "metadata.name", Placeholder: "$X", URLAppend: "?a=$X"},
+			{Path: "spec.nodeName", Placeholder: "$X"},
+		}},
+		{"lowercase placeholder", []TemplateVar{
+			{Path: "metadata.name", Placeholder: "$pod", URLAppend: "?pod=$pod"},
+		}},
+		{"placeholder without $", []TemplateVar{
+			{Path: "metadata.name", Placeholder: "POD", URLAppend: "?pod=POD"},
+		}},
+		{"neither urlAppend nor placeholder", []TemplateVar{
+			{Path: "metadata.name"},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{MenuItems: []MenuItem{{Title: "test", URL: "https://example.com", TemplateVars: tt.vars}}}
+			if err := ValidateConfig(&cfg); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+
+	// Explicit $VALUE placeholders may repeat, like the implicit default
+	cfg := Config{MenuItems: []MenuItem{{Title: "test", URL: "https://example.com", TemplateVars: []TemplateVar{
+		{Path: "metadata.name", Placeholder: "$VALUE", URLAppend: "?a=$VALUE"},
+		{Path: "spec.nodeName", Placeholder: "$VALUE", URLAppend: "&b=$VALUE"},
+	}}}}
+	if err := ValidateConfig(&cfg); err != nil {
+		t.Errorf("repeated $VALUE placeholder: %v", err)
+	}
+}
+
+// ---- Defaults, required vars and fallback paths ----
+
+const podRecommendedLabels = `{
+  "metadata": {
+    "name": "api-7d9f8-xk2lp",
+    "labels": {
+      "app.kubernetes.io/name": "api",
+      "app.kubernetes.io/instance": "api-prod"
+    }
+  }
+}`
+
+func TestTemplateVar_FallbackAndDefault(t *testing.T) {
+	nginx := podFromJSON(t, podNginxProd)
+	api := podFromJSON(t, podRecommendedLabels)
+	bare := podFromJSON(t, podNoLabels)
+
+	tv := TemplateVar{
+		Path:          "metadata.labels.app",
+		FallbackPaths: []string{`metadata.labels["app.kubernetes.io/name"]`, "metadata.name"},
+		URLAppend:     "?app=$VALUE",
+	}
+	cases := []struct {
+		name string
+		res  *Resource
+		want string
+	}{
+		{"primary path", nginx, "nginx"},
+		{"first fallback", api, "api"},
+		{"last fallback", bare, "bare-pod"},
+	}
+	for _, c := range cases {
+		if got := tv.resolve(c.res); got != c.want {
+			t.Errorf("%s: resolve = %q, want %q", c.name, got, c.want)
+		}
+	}
+
+	withDefault := TemplateVar{Path: "metadata.labels.env", Default: "all", URLAppend: "?env=$VALUE"}
+	if got := withDefault.resolve(api); got != "all" {
+		t.Errorf("default: resolve = %q, want all", got)
+	}
+	if got := withDefault.resolve(nil); got != "all" {
+		t.Errorf("default with nil resource: resolve = %q, want all", got)
+	}
+	if got := withDefault.resolve(nginx); got != "production" {
+		t.Errorf("resolved value should win over default: got %q", got)
+	}
+
+	keyed := TemplateVar{Path: "metadata.labels", Key: "app", FallbackPaths: []string{`metadata.labels["app.kubernetes.io/name"]`}}
+	if got := keyed.resolve(api); got != "api" {
+		t.Errorf("key then fallback: resolve = %q, want api", got)
+	}
+}
+
+func TestResolveURL_Default(t *testing.T) {
+	api := podFromJSON(t, podRecommendedLabels)
+
+	cfg := Config{MenuItems: []MenuItem{{
+		Title: "test", URL: "https://example.com",
+		TemplateVars: []TemplateVar{
+			{Path: "metadata.labels.env", Default: "all envs", URLAppend: "?env=$VALUE"},
+		},
+	}}}
+	if err := ValidateConfig(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := cfg.MenuItems[0].ResolveURL(api), "https://example.com?env=all+envs"; got != want {
+		t.Errorf("ResolveURL = %q, want %q", got, want)
+	}
+}
+
+func TestFilterMenuItems_Required(t *testing.T) {
+	nginx := podFromJSON(t, podNginxProd)
+	api := podFromJSON(t, podRecommendedLabels)
+
+	cfg := Config{MenuItems: []MenuItem{
+		{Title: "Needs app", URL: "https://a", TemplateVars: []TemplateVar{
+			{Path: "metadata.labels.app", Required: true, URLAppend: "?app=$VALUE"},
+		}},
+		{Title: "Needs app, with fallback", URL: "https://b", TemplateVars: []TemplateVar{
+			{Path: "metadata.labels.app", FallbackPaths: []string{`metadata.labels["app.kubernetes.io/name"]`}, Required: true, URLAppend: "?app=$VALUE"},
+		}},
+		{Title: "Needs env, with default", URL: "https://c", TemplateVars: []TemplateVar{
+			{Path: "metadata.labels.env", Default: "all", Required: true, URLAppend: "?env=$VALUE"},
+		}},
+		{Title: "Optional", URL: "https://d", TemplateVars: []TemplateVar{
+			{Path: "metadata.labels.app", URLAppend: "?app=$VALUE"},
+		}},
+	}}
+	if err := ValidateConfig(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := itemTitles(FilterMenuItems(cfg.MenuItems, nginx)), []string{"Needs app", "Needs app, with fallback", "Needs env, with default", "Optional"}; !slices.Equal(got, want) {
+		t.Errorf("nginx: got %q, want %q", got, want)
+	}
+	if got, want := itemTitles(FilterMenuItems(cfg.MenuItems, api)), []string{"Needs app, with fallback", "Needs env, with default", "Optional"}; !slices.Equal(got, want) {
+		t.Errorf("api: got %q, want %q", got, want)
+	}
+	if got, want := itemTitles(FilterMenuItems(cfg.MenuItems, nil)), []string{"Needs env, with default", "Optional"}; !slices.Equal(got, want) {
+		t.Errorf("nil: got %q, want %q", got, want)
+	}
+
+	if missing := cfg.MenuItems[0].MissingRequired(api); len(missing) != 1 || missing[0] != "metadata.labels.app" {
+		t.Errorf("MissingRequired = %v, want [metadata.labels.app]", missing)
+	}
+}
+
+func TestValidateConfig_InvalidFallbackPath(t *testing.T) {
+	cfg := Config{MenuItems: []MenuItem{{
+		Title: "test", URL: "http://test",
+		TemplateVars: []TemplateVar{{Path: "metadata.name", FallbackPaths: []string{`metadata.labels["x`}, URLAppend: "?x=$VALUE"}},
+	}}}
+	if err := ValidateConfig(&cfg); err == nil {
+		t.Error("expected error for malformed fallback path, got nil")
+	}
+}
+
+// ---- Transforms ----
+
+func TestTransforms(t *testing.T) {
+	tests := []struct {
+		name string
+		ts   []Transform
+		in   string
+		want string
+	}{
+		{
+			name: "strip registry from image",
+			ts:   []Transform{{Type: "regexReplace", Pattern: "^.*/", Replacement: ""}},
+			in:   "registry.example.com/team/api:1.2.3",
+			want: "api:1.2.3",
+		},
+		{
+			name: "image name without tag",
+			ts: []Transform{
+				{Type: "regexReplace", Pattern: "^.*/"},
+				{Type: "split", Separator: ":", Index: 0},
+			},
+			in:   "registry.example.com/team/api:1.2.3",
+			want: "api",
+		},
+		{
+			name: "image tag via negative index",
+			ts:   []Transform{{Type: "split", Separator: ":", Index: -1}},
+			in:   "registry.example.com:5000/api:1.2.3",
+			want: "1.2.3",
+		},
+		{
+			name: "split index out of range",
+			ts:   []Transform{{Type: "split", Separator: ":", Index: 3}},
+			in:   "api:1.2.3",
+			want: "",
+		},
+		{
+			name: "pod name before the ReplicaSet hash",
+			ts:   []Transform{{Type: "regexCapture", Pattern: `^(.*)-[a-z0-9]+-[a-z0-9]+$`}},
+			in:   "api-gateway-7d9f8c6b5-xk2lp",
+			want: "api-gateway",
+		},
+		{
+			name: "regexCapture explicit group",
+			ts:   []Transform{{Type: "regexCapture", Pattern: `^(\w+)-(\w+)$`, Group: 2}},
+			in:   "prod-eu",
+			want: "eu",
+		},
+		{
+			name: "regexCapture without groups returns match",
+			ts:   []Transform{{Type: "regexCapture", Pattern: `\d+`}},
+			in:   "node-42-a",
+			want: "42",
+		},
+		{
+			name: "regexCapture no match",
+			ts:   []Transform{{Type: "regexCapture", Pattern: `^v(\d+)$`}},
+			in:   "latest",
+			want: "",
+		},
+		{
+			name: "regexReplace with capture reference",
+			ts:   []Transform{{Type: "regexReplace", Pattern: `^(\w+)\.(\w+)$`, Replacement: "$2-$1"}},
+			in:   "eu.prod",
+			want: "prod-eu",
+		},
+		{
+			name: "trimPrefix and upper",
+			ts:   []Transform{{Type: "trimPrefix", Value: "team-"}, {Type: "upper"}},
+			in:   "team-platform",
+			want: "PLATFORM",
+		},
+		{
+			name: "trimSuffix and lower",
+			ts:   []Transform{{Type: "trimSuffix", Value: ".svc.cluster.local"}, {Type: "lower"}},
+			in:   "API.default.svc.cluster.local",
+			want: "api.default",
+		},
+		{
+			name: "truncate counts characters",
+			ts:   []Transform{{Type: "truncate", Length: 4}},
+			in:   "café-latte",
+			want: "café",
+		},
+		{
+			name: "truncate shorter value",
+			ts:   []Transform{{Type: "truncate", Length: 40}},
+			in:   "short",
+			want: "short",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := range tt.ts {
+				if err := tt.ts[i].compile(); err != nil {
+					t.Fatalf("compile: %v", err)
+				}
+			}
+			if got := applyTransforms(tt.ts, tt.in); got != tt.want {
+				t.Errorf("applyTransforms(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveURL_Transforms(t *testing.T) {
+	pd := podFromJSON(t, podNginxProd)
+
+	cfg := Config{MenuItems: []MenuItem{{
+		Title: "test", URL: "https://example.com",
+		TemplateVars: []TemplateVar{
+			{
+				Path:        "spec.containers.0.image",
+				Placeholder: "$IMAGE",
+				Transforms:  []Transform{{Type: "split", Separator: ":", Index: 0}},
+				URLAppend:   "?image=$IMAGE",
+			},
+			{
+				Path:       "metadata.name",
+				Transforms: []Transform{{Type: "regexCapture", Pattern: `^v(\d+)$`}},
+				Default:    "unversioned",
+				URLAppend:  "&version=$VALUE",
+			},
+		},
+	}}}
+	if err := ValidateConfig(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	want := "https://example.com?image=nginx&version=unversioned"
+	if got := cfg.MenuItems[0].ResolveURL(pd); got != want {
+		t.Errorf("ResolveURL = %q, want %q", got, want)
+	}
+}
+
+func TestValidateConfig_Transforms(t *testing.T) {
+	tests := []struct {
+		name string
+		tr   Transform
+	}{
+		{"missing type", Transform{}},
+		{"unknown type", Transform{Type: "reverse"}},
+		{"invalid regex", Transform{Type: "regexReplace", Pattern: "[bad"}},
+		{"missing pattern", Transform{Type: "regexCapture"}},
+		{"group out of range", Transform{Type: "regexCapture", Pattern: "(a)", Group: 2}},
+		{"split without separator", Transform{Type: "split"}},
+		{"trimPrefix without value", Transform{Type: "trimPrefix"}},
+		{"truncate without length", Transform{Type: "truncate"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{MenuItems: []MenuItem{{
+				Title: "test", URL: "http://test",
+				TemplateVars: []TemplateVar{{Path: "metadata.name", URLAppend: "?x=$VALUE", Transforms: []Transform{tt.tr}}},
+			}}}
+			if err := ValidateConfig(&cfg); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+// ---- Time ranges ----
+
+const podRestarted = `{
+  "metadata": {
+    "name": "api-7d9f8-xk2lp",
+    "creationTimestamp": "2026-10-16T10:00:00Z"
+  },
+  "status": {
+    "startTime": "2026-10-16T10:00:05Z",
+    "containerStatuses": [
+      {
+        "name": "api",
+        "restartCount": 2,
+        "lastState": {"terminated": {"finishedAt": "2026-10-16T11:30:00Z"}}
+      },
+      {
+        "name": "sidecar",
+        "restartCount": 1,
+        "lastState": {"terminated": {"finishedAt": "2026-10-16T11:45:00Z"}}
+      },
+      {
+        "name": "init",
+        "restartCount": 0,
+        "lastState": {}
+      }
+    ]
+  }
+}`
+
+// fixClock sets timeNow to a fixed instant for the duration of the test.
+func fixClock(t *testing.T, rfc3339 string) time.Time {
+	t.Helper()
+	now, err := time.Parse(time.RFC3339, rfc3339)
+	if err != nil {
+		t.Fatal(err)
+	}
+	orig := timeNow
+	timeNow = func() time.Time { return now }
+	t.Cleanup(func() { timeNow = orig })
+	return now
+}
+
+func TestTimeTransform(t *testing.T) {
+	fixClock(t, "2026-10-16T12:00:00Z")
+
+	tests := []struct {
+		name string
+		tr   Transform
+		in   string
+		want string
+	}{
+		{"epoch millis", Transform{Type: "time", Format: "epochMillis"}, "2026-10-16T10:00:00Z", "1792144800000"},
+		{"epoch seconds with padding", Transform{Type: "time", Format: "epochSeconds", Offset: "-5m"}, "2026-10-16T10:00:00Z", "1792144500"},
+		{"rfc3339 is the default format", Transform{Type: "time", Offset: "1h"}, "2026-10-16T12:00:00+02:00", "2026-10-16T11:00:00Z"},
+		{"relative", Transform{Type: "time", Format: "relative", Offset: "-5m"}, "2026-10-16T10:00:00Z", "now-125m"},
+		{"relative rounds up", Transform{Type: "time", Format: "relative"}, "2026-10-16T11:59:30Z", "now-1m"},
+		{"relative future", Transform{Type: "time", Format: "relative"}, "2026-10-16T13:00:00Z", "now"},
+		{"now", Transform{Type: "time", Format: "epochMillis"}, "now", "1792152000000"},
+		{"latest of a list", Transform{Type: "time"}, "2026-10-16T11:30:00Z,2026-10-16T11:45:00Z", "2026-10-16T11:45:00Z"},
+		{"earliest of a list", Transform{Type: "time", Pick: "earliest"}, "2026-10-16T11:30:00Z,2026-10-16T11:45:00Z", "2026-10-16T11:30:00Z"},
+		{"not a timestamp", Transform{Type: "time"}, "yesterday", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.tr.compile(); err != nil {
+				t.Fatal(err)
+			}
+			if got := tt.tr.apply(tt.in); got != tt.want {
+				t.Errorf("apply(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveURL_TimeRange(t *testing.T) {
+	fixClock(t, "2026-10-16T12:00:00Z")
+	pd := podFromJSON(t, podRestarted)
+
+	cfg := Config{MenuItems: []MenuItem{
+		{
+			Title: "Datadog since creation", URL: "https://app.datadoghq.com/logs",
+			TemplateVars: []TemplateVar{
+				{
+					Path: "metadata.creationTimestamp", Placeholder: "$FROM",
+					Transforms: []Transform{{Type: "time", Format: "epochMillis", Offset: "-5m"}},
+				},
+				{
+					Value: "now", Placeholder: "$TO",
+					Transforms: []Transform{{Type: "time", Format: "epochMillis"}},
+					URLAppend:  "?from_ts=$FROM&to_ts=$TO&live=true",
+				},
+			},
+		},
+		{
+			Title: "Grafana since last restart", URL: "https://grafana.example.com/d/pods",
+			TemplateVars: []TemplateVar{
+				{
+					Path:          "status.containerStatuses.#.lastState.terminated.finishedAt",
+					FallbackPaths: []string{"status.startTime"},
+					Transforms:    []Transform{{Type: "time", Format: "relative", Offset: "-10m"}},
+					URLAppend:     "?from=$VALUE&to=now",
+				},
+			},
+		},
+	}}
+	if err := ValidateConfig(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "https://app.datadoghq.com/logs?from_ts=1792144500000&to_ts=1792152000000&live=true"
+	if got := cfg.MenuItems[0].ResolveURL(pd); got != want {
+		t.Errorf("Datadog URL = %q, want %q", got, want)
+	}
+	want = "https://grafana.example.com/d/pods?from=now-25m&to=now"
+	if got := cfg.MenuItems[1].ResolveURL(pd); got != want {
+		t.Errorf("Grafana URL = %q, want %q", got, want)
+	}
+
+	// Without restarts, fall back to the pod's start time
+	fresh := podFromJSON(t, `{"status": {"startTime": "2026-10-16T11:00:00Z"}}`)
+	want = "https://grafana.example.com/d/pods?from=now-70m&to=now"
+	if got := cfg.MenuItems[1].ResolveURL(fresh); got != want {
+		t.Errorf("Grafana URL without restarts = %q, want %q", got, want)
+	}
+}
+
+func TestResolveURL_TemplateTimeHelpers(t *testing.T) {
+	fixClock(t, "2026-10-16T12:00:00Z")
+	pd := podFromJSON(t, podRestarted)
+
+	tmpl := `https://app.datadoghq.com/logs?from_ts={{path "metadata.creationTimestamp" | parseTime | offset "-5m" | epochMillis}}` +
+		`&to_ts={{now | epochMillis}}` +
+		`&restart={{path "status.containerStatuses.#.lastState.terminated.finishedAt" | parseTime | rfc3339}}` +
+		`&from={{path "status.startTime" | parseTime | relative}}`
+	cfg := Config{MenuItems: []MenuItem{{Title: "test", URL: "https://fallback", URLTemplate: tmpl}}}
+	if err := ValidateConfig(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	want := "https://app.datadoghq.com/logs?from_ts=1792144500000&to_ts=1792152000000&restart=2026-10-16T11%3A45%3A00Z&from=now-120m"
+	if got := cfg.MenuItems[0].ResolveURL(pd); got != want {
+		t.Errorf("ResolveURL = %q, want %q", got, want)
+	}
+
+	// A missing timestamp makes parseTime fail, so the item falls back to url
+	if got := cfg.MenuItems[0].ResolveURL(podFromJSON(t, podNoLabels)); got != "https://fallback" {
+		t.Errorf("ResolveURL without timestamps = %q, want fallback", got)
+	}
+}
+
+func TestValidateConfig_TimeTransformAndValue(t *testing.T) {
+	tests := []struct {
+		name string
+		tv   TemplateVar
+	}{
+		{"unknown format", TemplateVar{Path: "metadata.creationTimestamp", URLAppend: "?t=$VALUE", Transforms: []Transform{{Type: "time", Format: "unix"}}}},
+		{"invalid offset", TemplateVar{Path: "metadata.creationTimestamp", URLAppend: "?t=$VALUE", Transforms: []Transform{{Type: "time", Offset: "5 minutes"}}}},
+		{"invalid pick", TemplateVar{Path: "metadata.creationTimestamp", URLAppend: "?t=$VALUE", Transforms: []Transform{{Type: "time", Pick: "first"}}}},
+		{"both path and value", TemplateVar{Path: "metadata.name", Value: "now", URLAppend: "?t=$VALUE"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{MenuItems: []MenuItem{{Title: "test", URL: "http://test", TemplateVars: []TemplateVar{tt.tv}}}}
+			if err := ValidateConfig(&cfg); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+// ---- Explain ----
+
+func TestConditionTrace_AgreesWithEvaluate(t *testing.T) {
+	pods := []*Resource{
+		podFromJSON(t, podNginxProd),
+		podFromJSON(t, podRedisStaging),
+		podFromJSON(t, podNoLabels),
+		podFromJSON(t, podWithResources),
+		podFromJSON(t, podCrossField),
+	}
+	conds := []string{
+		`{"path": "metadata.labels", "keyPattern": "app", "valuePattern": "nginx"}`,
+		`{"path": "metadata.labels", "keyPattern": "app", "invert": true}`,
+		`{"path": "spec.containers.#.image", "valuePattern": ".*:1\\..*"}`,
+		`{"path": "status.containerStatuses.#.restartCount", "op": "gt", "value": 3}`,
+		`{"path": "spec.nodeName", "op": "exists", "invert": true}`,
+		`{"path": "status.hostIP", "valueFromPath": "metadata.annotations[\"example.com/expected-host\"]"}`,
+	}
+	for _, raw := range conds {
+		c := conditionFromJSON(t, raw)
+		for _, pd := range pods {
+			tr := c.Trace(pd)
+			if got := c.Evaluate(pd); tr.Result != got {
+				t.Errorf("%s on %s: Trace().Result = %v, Evaluate() = %v", raw, pd.Name, tr.Result, got)
+			}
+			if tr.Result != (tr.Matched != tr.Invert) {
+				t.Errorf("%s on %s: inconsistent trace %+v", raw, pd.Name, tr)
+			}
+		}
+	}
+}
+
+func TestConditionTrace(t *testing.T) {
+	pd := podFromJSON(t, podNginxProd)
+
+	c := conditionFromJSON(t, `{"path": "metadata.labels", "keyPattern": "app", "valuePattern": "ng.*"}`)
+	tr := c.Trace(pd)
+	if !tr.Found || !tr.Matched || !tr.Result || tr.MatchedEntry != "app=nginx" {
+		t.Errorf("Trace() = %+v, want match on app=nginx", tr)
+	}
+	if tr.KeyRegex != "^app$" || tr.ValueRegex != "^ng.*$" {
+		t.Errorf("Trace() regexes = %q, %q", tr.KeyRegex, tr.ValueRegex)
+	}
+
+	c = conditionFromJSON(t, `{"path": "spec.nodeName", "valuePattern": "staging-.*", "invert": true}`)
+	tr = c.Trace(pd)
+	if tr.Matched || !tr.Result || !tr.Invert {
+		t.Errorf("Trace() = %+v, want unmatched, inverted to true", tr)
+	}
+
+	c = conditionFromJSON(t, `{"path": "spec.priority", "op": "gt", "value": 0}`)
+	if tr = c.Trace(pd); tr.Found || tr.Result {
+		t.Errorf("Trace() = %+v, want path not found", tr)
+	}
+}
+
+func TestExplain(t *testing.T) {
+	pd := podFromJSON(t, podNginxProd)
+	raw := `{"menuItems": [
+		{"title": "Nodes only", "url": "http://nodes", "kinds": ["node"]},
+		{
+			"title": "Nginx prod", "url": "http://nginx",
+			"filters": {
+				"conditions": [{"path": "metadata.labels", "keyPattern": "app", "valuePattern": "nginx"}],
+				"anyOf": [
+					{"conditions": [{"path": "metadata.labels", "keyPattern": "env", "valuePattern": "staging"}]},
+					{"expression": "labels.env == \"production\""}
+				],
+				"not": {"conditions": [{"path": "status.phase", "valuePattern": "Failed"}]}
+			}
+		},
+		{
+			"title": "Needs owner", "url": "http://owner",
+			"templateVars": [{"path": "metadata.labels.owner", "urlAppend": "?owner=$VALUE", "required": true}]
+		}
+	]}`
+	var cfg Config
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateConfig(&cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	var traces []ItemTrace
+	for _, item := range cfg.MenuItems {
+		traces = append(traces, item.Explain(pd))
+	}
+	shown := FilterMenuItems(cfg.MenuItems, pd)
+	for i, tr := range traces {
+		want := false
+		for _, it := range shown {
+			want = want || it.Title == cfg.MenuItems[i].Title
+		}
+		if tr.Shown != want {
+			t.Errorf("%s: Explain().Shown = %v, FilterMenuItems shows it: %v", tr.Title, tr.Shown, want)
+		}
+	}
+
+	if traces[0].KindMatch {
+		t.Error("Nodes only: expected kind mismatch")
+	}
+	f := traces[1].Filters
+	if f == nil || !f.Passed || len(f.AnyOf) != 2 || f.AnyOf[0].Passed || !f.AnyOf[1].Expression.Result || f.Not.Passed {
+		t.Errorf("Nginx prod: unexpected filter trace %+v", f)
+	}
+	if traces[1].URL != "http://nginx" {
+		t.Errorf("Nginx prod: URL = %q", traces[1].URL)
+	}
+	if len(traces[2].MissingRequired) != 1 || traces[2].MissingRequired[0] != "metadata.labels.owner" {
+		t.Errorf("Needs owner: MissingRequired = %v", traces[2].MissingRequired)
+	}
+
+	var b strings.Builder
+	writeExplain(&b, traces)
+	out := b.String()
+	for _, want := range []string{
+		"✗ Nodes only\n    ✗ kinds node\n",
+		"✓ Nginx prod\n    ✓ metadata.labels key ^app$ value ^nginx$: matched app=nginx\n",
+		"    ✗ anyOf[0]\n        ✗ metadata.labels key ^env$ value ^staging$: no match in {",
+		"        ✓ expression labels.env == \"production\"\n",
+		"    ✓ not (inner group fail)\n        ✗ status.phase value ^Failed$: no match in \"Running\"\n",
+		"    → http://nginx\n",
+		"✗ Needs owner\n    ✗ required templateVars empty: metadata.labels.owner\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("writeExplain output missing %q:\n%s", want, out)
+		}
+	}
+
+	data, err := json.Marshal(traces)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"matchedEntry":"app=nginx"`) {
+		t.Errorf("JSON output missing matched entry: %s", data)
+	}
+}
+
+// ---- urlTemplate tests ----
+
+func TestResolveURL_Template(t *testing.T) {
+	pd := podFromJSON(t, podNginxProd)
+
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{
+			name: "fields in path segments",
+			tmpl: "https://console.example.com/namespaces/{{.metadata.namespace}}/pods/{{.metadata.name}}",
+			want: "https://console.example.com/namespaces/production/pods/nginx-abc123",
+		},
+		{
+			name: "label and annotation lookup",
+			tmpl: `https://example.com/{{label "app"}}?port={{annotation "prometheus.io/port"}}`,
+			want: "https://example.com/nginx?port=9090",
+		},
+		{
+			name: "path with wildcard and join",
+			tmpl: `https://example.com/?images={{path "spec.containers.#.image" | join "|"}}`,
+			want: "https://example.com/?images=nginx%3A1.25%7Cenvoy%3A1.28",
+		},
+		{
+			name: "default for missing values",
+			tmpl: `https://example.com/?version={{label "version" | default "latest"}}&app={{label "app" | default "none"}}`,
+			want: "https://example.com/?version=latest&app=nginx",
+		},
+		{
+			name: "lower and urlquery",
+			tmpl: `https://example.com/?phase={{path "status.phase" | lower}}&q={{"a b&c" | urlquery}}`,
+			want: "https://example.com/?phase=running&q=a+b%26c",
+		},
+		{
+			name: "conditional query parameter",
+			tmpl: `https://example.com/{{with label "team"}}?team={{.}}{{end}}`,
+			want: "https://example.com/?team=platform",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{MenuItems: []MenuItem{{Title: "test", URLTemplate: tt.tmpl}}}
+			if err := ValidateConfig(&cfg); err != nil {
+				t.Fatal(err)
+			}
+			if got := cfg.MenuItems[0].ResolveURL(pd); got != tt.want {
+				t.Errorf("ResolveURL = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveURL_TemplateEscaping(t *testing.T) {
+	pd := podFromJSON(t, `{"metadata": {"name": "a b/c", "labels": {"app": "x&y#z", "team": "a/b"}, "annotations": {"q": "a%20b"}}}`)
+
+	tests := []struct {
+		name string
+		tmpl string
+		want string
+	}{
+		{
+			name: "values are escaped by default",
+			tmpl: `https://h/{{label "app"}}/{{.metadata.name}}`,
+			want: "https://h/x&y%23z/a%20b%2Fc",
+		},
+		{
+			name: "query values are query-escaped",
+			tmpl: `https://h/?app={{label "app"}}&pod={{.metadata.name}}`,
+			want: "https://h/?app=x%26y%23z&pod=a+b%2Fc",
+		},
+		{
+			name: "fragment query",
+			tmpl: `https://h/#/logs?app={{label "app"}}`,
+			want: "https://h/#/logs?app=x%26y%23z",
+		},
+		{
+			name: "branch bodies",
+			tmpl: `https://h/{{with label "team"}}{{.}}?team={{.}}{{end}}&app={{label "app"}}`,
+			want: "https://h/a%2Fb?team=a%2Fb&app=x%26y%23z",
+		},
+		{
+			name: "raw opts out",
+			tmpl: `https://h/?q={{annotation "q" | raw}}`,
+			want: "https://h/?q=a%20b",
+		},
+		{
+			name: "explicit escaping is not repeated",
+			tmpl: `https://h/{{pathescape .metadata.name}}?app={{label "app" | urlquery}}`,
+			want: "https://h/a%20b%2Fc?app=x%26y%23z",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{MenuItems: []MenuItem{{Title: "test", URLTemplate: tt.tmpl}}}
+			if err := ValidateConfig(&cfg); err != nil {
+				t.Fatal(err)
+			}
+			got := cfg.MenuItems[0].ResolveURL(pd)
+			if got != tt.want {
+				t.Errorf("ResolveURL = %q, want %q", got, tt.want)
+			}
+			if _, err := url.Parse(got); err != nil {
+				t.Errorf("ResolveURL produced an unparseable URL: %v", err)
+			}
+		})
+	}
+}
+
+func TestResolveURL_TemplateFallback(t *testing.T) {
+	cfg := Config{MenuItems: []MenuItem{
+		{Title: "helpers", URLTemplate: `https://example.com/?app={{label "app" | default "all"}}`},
+		{Title: "fields", URL: "https://example.com/pods", URLTemplate: "https://example.com/pods/{{.metadata.name}}"},
+	}}
 	if err := ValidateConfig(&cfg); err != nil {
 		t.Fatal(err)
 	}
-
-	got := cfg.MenuItems[0].ResolveURL(pd)
-	want := "https://example.com?app=nginx&extra=nginx"
-	if got != want {
-		t.Errorf("ResolveURL = %q, want %q", got, want)
+	// Helpers render against a nil resource
+	if got, want := cfg.MenuItems[0].ResolveURL(nil), "https://example.com/?app=all"; got != want {
+		t.Errorf("ResolveURL(nil) = %q, want %q", got, want)
+	}
+	// Field access on a nil resource fails and falls back to url
+	if got, want := cfg.MenuItems[1].ResolveURL(nil), "https://example.com/pods"; got != want {
+		t.Errorf("ResolveURL(nil) = %q, want %q", got, want)
 	}
 }
 
-func TestResolveURL_MissingKey(t *testing.T) {
+func TestResolveURL_TemplateMissingField(t *testing.T) {
 	pd := podFromJSON(t, podNginxProd)
 
-	item := MenuItem{
-		Title: "test", URL: "https://example.com",
-		TemplateVars: []TemplateVar{
-			{Path: "metadata.labels.nonexistent", URLAppend: "?x=$VALUE"},
-		},
-	}
-	cfg := Config{MenuItems: []MenuItem{item}}
+	cfg := Config{MenuItems: []MenuItem{
+		{Title: "missing label", URL: "https://example.com/pods", URLTemplate: "https://example.com/{{.metadata.labels.missing}}"},
+		{Title: "missing label, no url", URLTemplate: "https://example.com/{{.metadata.labels.missing}}"},
+		{Title: "missing object, no url", URLTemplate: "https://example.com/{{.spec.missing.name}}"},
+		{Title: "present", URLTemplate: "https://example.com/{{.metadata.name}}"},
+	}}
 	if err := ValidateConfig(&cfg); err != nil {
 		t.Fatal(err)
 	}
-
-	got := cfg.MenuItems[0].ResolveURL(pd)
-	want := "https://example.com" // nothing appended
-	if got != want {
+	// A field that doesn't exist is a failure, not "<no value>" in the URL
+	if got, want := cfg.MenuItems[0].ResolveURL(pd), "https://example.com/pods"; got != want {
 		t.Errorf("ResolveURL = %q, want %q", got, want)
 	}
+	for i, want := range []bool{false, true, true, false} {
+		if err := cfg.MenuItems[i].TemplateError(pd); (err != nil) != want {
+			t.Errorf("%s: TemplateError = %v, want error %v", cfg.MenuItems[i].Title, err, want)
+		}
+	}
+	// Without url to fall back to, items whose template fails are hidden
+	result := FilterMenuItems(cfg.MenuItems, pd)
+	if len(result) != 2 || result[0].Title != "missing label" || result[1].Title != "present" {
+		t.Errorf("FilterMenuItems = %v, want [missing label, present]", result)
+	}
 }
 
-func TestResolveURL_MultipleVars(t *testing.T) {
-	pd := podFromJSON(t, podNginxProd)
-
-	item := MenuItem{
-		Title: "test", URL: "https://example.com",
-		TemplateVars: []TemplateVar{
-			{Path: "metadata.labels.app", URLAppend: "?app=$VALUE"},
-			{Path: "metadata.name", URLAppend: "&pod=$VALUE"},
-		},
-	}
-	cfg := Config{MenuItems: []MenuItem{item}}
+func TestFilterMenuItems_TemplateNilResource(t *testing.T) {
+	cfg := Config{MenuItems: []MenuItem{
+		{Title: "fields", URLTemplate: "https://example.com/nodes/{{.spec.nodeName}}"},
+		{Title: "fields with url", URL: "https://example.com/nodes", URLTemplate: "https://example.com/nodes/{{.spec.nodeName}}"},
+		{Title: "helpers", URLTemplate: `https://example.com/?app={{label "app" | default "all"}}`},
+	}}
 	if err := ValidateConfig(&cfg); err != nil {
 		t.Fatal(err)
 	}
-
-	got := cfg.MenuItems[0].ResolveURL(pd)
-	want := "https://example.com?app=nginx&pod=nginx-abc123"
-	if got != want {
-		t.Errorf("ResolveURL = %q, want %q", got, want)
+	// When kubectl get fails there is no resource to render fields from
+	result := FilterMenuItems(cfg.MenuItems, nil)
+	if len(result) != 2 || result[0].Title != "fields with url" || result[1].Title != "helpers" {
+		t.Errorf("FilterMenuItems(nil) = %v, want [fields with url, helpers]", result)
+	}
+	for _, it := range result {
+		if it.ResolveURL(nil) == "" {
+			t.Errorf("%s: ResolveURL(nil) is empty", it.Title)
+		}
 	}
 }
 
-func TestResolveURL_NilPod(t *testing.T) {
-	item := MenuItem{
-		Title: "test", URL: "https://example.com",
-		TemplateVars: []TemplateVar{
-			{Path: "metadata.labels.app", URLAppend: "?app=$VALUE"},
-		},
-	}
-	cfg := Config{MenuItems: []MenuItem{item}}
-	if err := ValidateConfig(&cfg); err != nil {
-		t.Fatal(err)
+func TestValidateConfig_URLTemplate(t *testing.T) {
+	tests := []struct {
+		name string
+		item MenuItem
+	}{
+		{"parse error", MenuItem{Title: "test", URLTemplate: "https://example.com/{{.metadata.name"}},
+		{"unknown function", MenuItem{Title: "test", URLTemplate: `https://example.com/{{nope "x"}}`}},
+		{"both urlTemplate and templateVars", MenuItem{
+			Title: "test", URLTemplate: "https://example.com/",
+			TemplateVars: []TemplateVar{{Path: "metadata.name", URLAppend: "?x=$VALUE"}},
+		}},
+		{"neither url nor urlTemplate", MenuItem{Title: "test"}},
 	}
-
-	got := cfg.MenuItems[0].ResolveURL(nil)
-	want := "https://example.com"
-	if got != want {
-		t.Errorf("ResolveURL(nil) = %q, want %q", got, want)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{MenuItems: []MenuItem{tt.item}}
+			if err := ValidateConfig(&cfg); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
 	}
 }
 
@@ -703,6 +3345,574 @@ func TestValidateConfig_DefaultPatterns(t *testing.T) {
 	}
 }
 
+// ---- Lint ----
+
+func TestLintConfig(t *testing.T) {
+	data := `{
+  "menuItems": [
+    {
+      "title": "Logs",
+      "url": "https://logs.example.com",
+      "templateVar": [{"path": "metadata.name"}]
+    },
+    {
+      "title": "Logs",
+      "url": "logs.example.com/search",
+      "filters": {
+        "conditions": [
+          {"path": "status.phase", "valuePattern": "Running"},
+          {"path": "status.phase", "valuePattern": "Pending"},
+          {"path": "metadata.name", "valuePattern": "^api-.*"}
+        ]
+      }
+    },
+    {
+      "title": "Broken",
+      "url": "https://example.com",
+      "filters": {"conditions": [{"path": "metadata.labels", "keyPattern": "[invalid"}]}
+    },
+    {
+      "title": "Unused value",
+      "url": "https://example.com",
+      "Kinds": ["pod"],
+      "templateVars": [
+        {"path": "metadata.name", "urlAppend": "?q=static"},
+        {"path": "metadata.namespace", "placeholder": "$NS"},
+        {"path": "spec.nodeName", "placeholder": "$NODE"},
+        {"path": "metadata.uid", "urlAppend": "&node=$NODE"}
+      ]
+    },
+    {
+      "title": "Not and itself",
+      "url": "https://example.com",
+      "filters": {
+        "anyOf": [{
+          "conditions": [
+            {"path": "metadata.labels", "keyPattern": "app"},
+            {"path": "metadata.labels", "keyPattern": "app", "invert": true}
+          ]
+        }]
+      }
+    }
+  ]
+}`
+	var got []string
+	for _, p := range LintConfig([]byte(data)) {
+		got = append(got, p.String())
+	}
+	want := []string{
+		`6:7: error: unknown field "templateVar" (did you mean "templateVars"?)`,
+		`9:7: warning: duplicate title "Logs" (also menuItems[0])`,
+		`10:7: warning: url "logs.example.com/search" has no scheme`,
+		`14:11: warning: unreachable: contradicts conditions[0] (a value can't be both "Running" and "Pending"), so this group never passes`,
+		`15:37: warning: valuePattern "^api-.*" is anchored twice; patterns are implicitly anchored with ^...$`,
+		`19:5: error: menuItems[2] (Broken) conditions[0] invalid keyPattern "[invalid": error parsing regexp: missing closing ]: ` + "`[invalid$`",
+		`27:7: warning: field "Kinds" only matches "kinds" case-insensitively`,
+		`29:35: warning: urlAppend "?q=static" doesn't contain $VALUE, so the value of metadata.name is never inserted`,
+		`30:40: warning: placeholder $NS is never used`,
+		`32:34: warning: urlAppend "&node=$NODE" doesn't contain $VALUE, so the value of metadata.uid is never inserted`,
+		`42:13: warning: unreachable: contradicts conditions[0] (one is the inverse of the other), so this group never passes`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("LintConfig() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLintConfig_DecodeErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"syntax error", "{\n  \"menuItems\": [\n    {\"title\": \"a\",}\n  ]\n}", "3:19: error: invalid character"},
+		{"wrong type", "{\n  \"menuItems\": [\n    {\"title\": 1}\n  ]\n}", "3:16: error: json: cannot unmarshal number"},
+		{"truncated", "{\"menuItems\": [", "1:16: error: unexpected end of JSON input"},
+		{"trailing data", "{\"menuItems\": []}\n}", "2:1: error: unexpected content"},
+		{"empty", "", "1:1: error: config is empty"},
+		{"no items", "{\"menuItems\": []}", "1:1: error: no menu items"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := LintConfig([]byte(tt.data))
+			if len(problems) == 0 || !strings.HasPrefix(problems[len(problems)-1].String(), tt.want) {
+				t.Errorf("LintConfig() = %v, want %q...", problems, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintConfig_Contradictions(t *testing.T) {
+	pd := podFromJSON(t, `{"metadata": {"name": "nginx-abc123", "labels": {"app": "nginx", "env": "production"}, "finalizers": ["a", "b"]}, "status": {"conditions": [{"type": "Ready"}, {"type": "Initialized"}]}}`)
+
+	tests := []struct {
+		name       string
+		conditions string
+		want       bool // reported as unreachable
+	}{
+		{"labels map", `[{"path": "metadata.labels", "valuePattern": "nginx"}, {"path": "metadata.labels", "valuePattern": "production"}]`, false},
+		{"array", `[{"path": "metadata.finalizers", "valuePattern": "a"}, {"path": "metadata.finalizers", "valuePattern": "b"}]`, false},
+		{"array of maps", `[{"path": "status.conditions", "keyPattern": "type", "valuePattern": "Ready"}, {"path": "status.conditions", "keyPattern": "type", "valuePattern": "Initialized"}]`, false},
+		{"label with literal key", `[{"path": "metadata.labels", "keyPattern": "app", "valuePattern": "nginx"}, {"path": "metadata.labels", "keyPattern": "app", "valuePattern": "redis"}]`, true},
+		{"label path", `[{"path": "metadata.labels.app", "valuePattern": "nginx"}, {"path": "metadata.labels.app", "op": "eq", "value": "redis"}]`, true},
+		{"scalar field", `[{"path": "metadata.name", "valuePattern": "nginx-abc123"}, {"path": "metadata.name", "valuePattern": "redis"}]`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := `{"menuItems": [{"title": "a", "url": "https://a", "filters": {"conditions": ` + tt.conditions + `}}]}`
+			problems := LintConfig([]byte(data))
+			if got := len(problems) > 0; got != tt.want {
+				t.Errorf("LintConfig() = %v, want unreachable %v", problems, tt.want)
+			}
+			if tt.want {
+				return
+			}
+			// Not flagged because both really can match
+			cfg, err := decodeConfig("config.json", []byte(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := ValidateConfig(&cfg); err != nil {
+				t.Fatal(err)
+			}
+			if !cfg.MenuItems[0].Matches(pd) {
+				t.Error("Matches = false, want true")
+			}
+		})
+	}
+}
+
+func TestLintConfig_PlaceholderWithoutUse(t *testing.T) {
+	data := `{"menuItems": [{"title": "a", "url": "https://a", "templateVars": [{"path": "metadata.name"}]}]}`
+	problems := LintConfig([]byte(data))
+	if len(problems) != 1 || !strings.Contains(problems[0].Message, "has empty urlAppend") {
+		t.Errorf("LintConfig() = %v, want only the empty urlAppend error", problems)
+	}
+}
+
+func TestRunLint_ExitStatus(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want int
+	}{
+		{"clean", `{"menuItems": [{"title": "a", "url": "https://a"}]}`, 0},
+		{"warnings only", `{"menuItems": [{"title": "a", "url": "a.example.com"}]}`, 0},
+		{"error", `{"menuItems": [{"title": "a"}]}`, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			if got := runLint([]string{writeConfig(t, "config.json", tt.data)}, &out); got != tt.want {
+				t.Errorf("runLint() = %d, want %d\n%s", got, tt.want, out.String())
+			}
+		})
+	}
+	var out strings.Builder
+	if got := runLint([]string{filepath.Join(t.TempDir(), "missing.json")}, &out); got != 2 {
+		t.Errorf("runLint(missing) = %d, want 2", got)
+	}
+}
+
+func TestLintConfig_ShippedConfig(t *testing.T) {
+	data, err := os.ReadFile("config.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if problems := LintConfig(data); len(problems) > 0 {
+		t.Errorf("config.json has lint problems: %v", problems)
+	}
+}
+
+// ---- Config file formats ----
+
+func writeConfig(t *testing.T, name, data string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfig_Formats(t *testing.T) {
+	jsonCfg := `{
+  "menuItems": [{
+    "title": "Logs",
+    "description": "Pod logs",
+    "url": "https://logs.example.com",
+    "kinds": ["pod"],
+    "filters": {"conditions": [{"path": "status.containerStatuses.#.restartCount", "op": "gt", "value": 1}]},
+    "templateVars": [{"path": "metadata.name", "urlAppend": "?pod=$VALUE"}]
+  }]
+}`
+	yamlCfg := `menuItems:
+  - title: Logs
+    description: Pod logs
+    url: https://logs.example.com
+    kinds: [pod]
+    filters:
+      conditions:
+        - path: status.containerStatuses.#.restartCount
+          op: gt
+          value: 1
+    templateVars:
+      - path: metadata.name
+        urlAppend: "?pod=$VALUE"
+`
+	tomlCfg := `[[menuItems]]
+title = "Logs"
+description = "Pod logs"
+url = "https://logs.example.com"
+kinds = ["pod"]
+
+[[menuItems.filters.conditions]]
+path = "status.containerStatuses.#.restartCount"
+op = "gt"
+value = 1
+
+[[menuItems.templateVars]]
+path = "metadata.name"
+urlAppend = "?pod=$VALUE"
+`
+	want, err := LoadConfig(writeConfig(t, "config.json", jsonCfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct{ name, data string }{
+		{"config.yaml", yamlCfg},
+		{"config.yml", yamlCfg},
+		{"config.toml", tomlCfg},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadConfig(writeConfig(t, tt.name, tt.data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			gotJSON, _ := json.Marshal(got)
+			wantJSON, _ := json.Marshal(want)
+			if string(gotJSON) != string(wantJSON) {
+				t.Errorf("LoadConfig(%s) = %s, want %s", tt.name, gotJSON, wantJSON)
+			}
+			res := podFromJSON(t, podRestarted)
+			if items := FilterMenuItems(got.MenuItems, res); len(items) != 1 {
+				t.Errorf("FilterMenuItems() returned %d items, want 1", len(items))
+			}
+		})
+	}
+}
+
+func TestLoadConfig_FormatErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"config.json", "{\n  \"menuItems\": [\n    {\"title\": \"a\",}\n  ]\n}", "line 3, column 19"},
+		{"config.json", "{\n  \"menuItems\": [\n    {\"title\": 1}\n  ]\n}", "line 3, column 6: menuItems.0.title has the wrong type"},
+		{"config.yaml", "menuItems:\n  - title: a\n\turl: b\n", "yaml: line 2: found a tab character"},
+		{"config.yaml", "menuItems:\n  - title: a\n    kinds: pod\n", "line 3, column 5: menuItems.0.kinds has the wrong type"},
+		{"config.toml", "[[menuItems]]\ntitle = \"a\"\nurl = \n", "line 3"},
+		{"config.toml", "[[menuItems]]\ntitle = \"a\"\nurl = \"https://a\"\nkinds = \"pod\"\n", `line 4 (last key "menuItems.kinds")`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadConfig(writeConfig(t, tt.name, tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadConfig() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindConfig(t *testing.T) {
+	root := t.TempDir()
+	home := filepath.Join(root, "home")
+	xdg := filepath.Join(root, "xdg")
+	exe := filepath.Join(root, "bin")
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv(configEnv, "")
+	orig := executableDir
+	executableDir = func() string { return exe }
+	t.Cleanup(func() { executableDir = orig })
+
+	put := func(dir, name string) string {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("{}"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	check := func(flagPath, wantPath, wantSource string) {
+		t.Helper()
+		path, source := findConfig(flagPath)
+		if path != wantPath || source != wantSource {
+			t.Errorf("findConfig(%q) = %q, %q, want %q, %q", flagPath, path, source, wantPath, wantSource)
+		}
+	}
+
+	// Each step adds a higher-precedence config.
+	check("", filepath.Join(exe, "config.json"), "executable directory (not found)")
+	exeCfg := put(exe, "config.toml")
+	check("", exeCfg, "executable directory")
+	k9sCfg := put(filepath.Join(home, ".config", "k9s", "go-to-dashboard"), "config.yaml")
+	check("", k9sCfg, "k9s config directory")
+	xdgCfg := put(filepath.Join(xdg, "go-to-dashboard"), "config.yml")
+	check("", xdgCfg, "XDG config directory")
+	xdgJSON := put(filepath.Join(xdg, "go-to-dashboard"), "config.json")
+	check("", xdgJSON, "XDG config directory")
+	t.Setenv(configEnv, "/from/env.yaml")
+	check("", "/from/env.yaml", "$"+configEnv)
+	check("/from/flag.json", "/from/flag.json", "-config flag")
+
+	// Without XDG_CONFIG_HOME, the XDG directory is ~/.config.
+	t.Setenv(configEnv, "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	homeXDG := put(filepath.Join(home, ".config", "go-to-dashboard"), "config.json")
+	check("", homeXDG, "XDG config directory")
+}
+
+func TestLoadConfig_Include(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "team"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string]string{
+		"team/a.yaml": `menuItems:
+  - {id: logs, title: Team Logs, url: "https://logs.example.com"}
+  - {id: metrics, title: Metrics, url: "https://metrics.example.com"}
+  - {title: Runbook, url: "https://runbook.example.com"}
+`,
+		"team/b.json": `{"menuItems": [{"id": "logs", "title": "Team Logs v2", "url": "https://logs2.example.com"}]}`,
+		"config.json": `{
+  "include": ["team/*"],
+  "menuItems": [
+    {"id": "metrics", "disabled": true},
+    {"title": "Mine", "url": "https://mine.example.com"},
+    {"id": "logs", "title": "My Logs", "url": "https://logs.example.com/me"}
+  ]
+}`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg, err := LoadConfig(filepath.Join(dir, "config.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, item := range cfg.MenuItems {
+		got = append(got, item.Title+" "+filepath.Base(item.source))
+	}
+	want := "My Logs config.json, Runbook a.yaml, Mine config.json"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("menu items = %s, want %s", strings.Join(got, ", "), want)
+	}
+}
+
+func TestLoadConfig_IncludeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{"missing file", map[string]string{
+			"config.json": `{"include": ["team.json"]}`,
+		}, `include "team.json": stat`},
+		{"cycle", map[string]string{
+			"config.json": `{"include": ["team.json"]}`,
+			"team.json":   `{"include": ["config.json"]}`,
+		}, "include cycle"},
+		{"parse error in include", map[string]string{
+			"config.json": `{"include": ["team.yaml"], "menuItems": [{"title": "a", "url": "https://a"}]}`,
+			"team.yaml":   "menuItems:\n  - title: a\n    kinds: pod\n",
+		}, "team.yaml: parse config: line 3"},
+		{"invalid item in include", map[string]string{
+			"config.json": `{"include": ["team.json"], "menuItems": [{"title": "a", "url": "https://a"}]}`,
+			"team.json":   `{"menuItems": [{"title": "ok", "url": "https://ok"}, {"title": "b"}]}`,
+		}, "team.json: menuItems[1] (b) has empty url"},
+		{"everything disabled", map[string]string{
+			"config.json": `{"include": ["team.json"], "menuItems": [{"id": "a", "disabled": true}]}`,
+			"team.json":   `{"menuItems": [{"id": "a", "title": "a", "url": "https://a"}]}`,
+		}, "no menu items"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, data := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			_, err := LoadConfig(filepath.Join(dir, "config.json"))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("LoadConfig() error = %v, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLintConfig_Layers(t *testing.T) {
+	data := `{
+  "include": ["team/[*.json"],
+  "menuItems": [
+    {"id": "a", "title": "A", "url": "https://a"},
+    {"id": "a", "title": "B", "url": "https://b"},
+    {"disabled": true},
+    {"id": "c", "disabled": true}
+  ]
+}`
+	var got []string
+	for _, p := range LintConfig([]byte(data)) {
+		got = append(got, p.String())
+	}
+	want := []string{
+		`2:15: error: include "team/[*.json": syntax error in pattern`,
+		`5:6: warning: duplicate id "a" (also menuItems[0]); the later item replaces the earlier one`,
+		`6:6: warning: disabled item has no id, so it disables nothing`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("LintConfig() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestLintFile_Formats(t *testing.T) {
+	yamlCfg := `menuItems:
+  - title: Logs
+    description: Pod logs
+    url: logs.example.com
+    templateVar:
+      - path: metadata.name
+`
+	tomlCfg := `[[menuItems]]
+title = "Logs"
+description = "Pod logs"
+url = "logs.example.com"
+`
+	tests := []struct {
+		name, data string
+		want       []string
+	}{
+		{"config.yaml", yamlCfg, []string{
+			`4:5: warning: url "logs.example.com" has no scheme`,
+			`5:5: error: unknown field "templateVar" (did you mean "templateVars"?)`,
+		}},
+		{"config.toml", tomlCfg, []string{
+			`menuItems/0/url: warning: url "logs.example.com" has no scheme`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, p := range lintFile(tt.name, []byte(tt.data)) {
+				got = append(got, p.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("lintFile() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestLintFile_TypeErrors(t *testing.T) {
+	tests := []struct {
+		name, data string
+		want       string
+	}{
+		{"config.json", "{\"menuItems\": [\n  {\"title\": \"a\", \"url\": \"https://a\", \"kinds\": \"pod\"}\n]}", "2:52: error: json: cannot unmarshal string"},
+		{"config.yaml", "menuItems:\n  - title: a\n    url: https://a\n    kinds: pod\n", "4:5: error: json: cannot unmarshal string"},
+		{"config.toml", "[[menuItems]]\ntitle = \"a\"\nurl = \"https://a\"\nkinds = \"pod\"\n", `error: toml: line 4 (last key "menuItems.kinds")`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems := lintFile(tt.name, []byte(tt.data))
+			if len(problems) != 1 || !strings.HasPrefix(problems[0].String(), tt.want) {
+				t.Errorf("lintFile() = %v, want %q...", problems, tt.want)
+			}
+		})
+	}
+}
+
+// ---- JSON Schema ----
+
+func TestSchema_UpToDate(t *testing.T) {
+	var b strings.Builder
+	if err := runSchema(&b); err != nil {
+		t.Fatal(err)
+	}
+	golden, err := os.ReadFile("config.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != string(golden) {
+		t.Error("config.schema.json is out of date; regenerate it with: go run . schema > config.schema.json")
+	}
+}
+
+func TestSchema_DescriptionsMatchFields(t *testing.T) {
+	schema, err := GenerateSchema()
+	if err != nil {
+		t.Fatal(err) // a field without a description
+	}
+	defs := schema["$defs"].(map[string]interface{})
+	for key := range schemaDescriptions {
+		typ, field, ok := strings.Cut(key, ".")
+		obj := schema
+		if typ != "Config" {
+			def, found := defs[typ]
+			if !found {
+				t.Errorf("schemaDescriptions[%q]: no type %s in the schema", key, typ)
+				continue
+			}
+			obj = def.(map[string]interface{})
+		}
+		if !ok {
+			continue
+		}
+		if _, found := obj["properties"].(map[string]interface{})[field]; !found {
+			t.Errorf("schemaDescriptions[%q]: %s has no field %q", key, typ, field)
+		}
+	}
+	for key := range schemaEnums {
+		if _, ok := schemaDescriptions[key]; !ok {
+			t.Errorf("schemaEnums[%q] is not a documented field", key)
+		}
+	}
+}
+
+func TestSchema_TransformTypesCompile(t *testing.T) {
+	for _, typ := range schemaEnums["Transform.type"] {
+		tr := Transform{Type: typ, Pattern: "(x)", Separator: ",", Value: "x", Length: 1}
+		if err := tr.compile(); err != nil {
+			t.Errorf("transform type %q from the schema doesn't compile: %v", typ, err)
+		}
+	}
+}
+
+func TestSchema_RequiredFields(t *testing.T) {
+	schema, err := GenerateSchema()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defs := schema["$defs"].(map[string]interface{})
+	required := func(obj interface{}) string {
+		list, _ := obj.(map[string]interface{})["required"].([]string)
+		return strings.Join(list, ",")
+	}
+	for typ, want := range map[string]string{"MenuItem": "", "Condition": "path", "Transform": "type", "TemplateVar": ""} {
+		if got := required(defs[typ]); got != want {
+			t.Errorf("%s required = %q, want %q", typ, got, want)
+		}
+	}
+	if got := required(schema); got != "" {
+		t.Errorf("Config required = %q, want none", got)
+	}
+}
+
 // ---- anchorPattern tests ----
 
 func TestAnchorPattern(t *testing.T) {
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// configNames are the config file names looked for next to the executable,
+// in order of preference.
+var configNames = []string{"config.json", "config.yaml", "config.yml", "config.toml"}
+
+// configFormat returns "json", "yaml" or "toml" from a config file's (or
+// URL's) extension. Unknown extensions are read as JSON.
+func configFormat(path string) string {
+	if isConfigURL(path) {
+		if u, err := url.Parse(path); err == nil {
+			path = u.Path
+		}
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".toml":
+		return "toml"
+	}
+	return "json"
+}
+
+// sourceLocator returns the line and column of the value at a JSON pointer in
+// the original config file, if known.
+type sourceLocator func(ptr string) (line, col int, ok bool)
+
+// configToJSON converts config data to JSON, so every format is decoded with
+// the same json tags and number types. The locator maps JSON pointers back to
+// the original file; it is nil for TOML, whose decoder only reports positions
+// in its errors. TOML is therefore also decoded into a Config, so type errors
+// are reported by the TOML decoder with their line.
+func configToJSON(data []byte, format string) ([]byte, sourceLocator, error) {
+	switch format {
+	case "yaml":
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, nil, err
+		}
+		var v interface{}
+		if err := doc.Decode(&v); err != nil {
+			return nil, nil, err
+		}
+		out, err := json.Marshal(v)
+		if err != nil {
+			return nil, nil, fmt.Errorf("yaml: %w", err)
+		}
+		return out, yamlLocator(&doc), nil
+	case "toml":
+		var v map[string]interface{}
+		if _, err := toml.Decode(string(data), &v); err != nil {
+			return nil, nil, err
+		}
+		// field names match the json tags case-insensitively, as TOML keys
+		// are matched to fields
+		if _, err := toml.Decode(string(data), new(Config)); err != nil {
+			return nil, nil, err
+		}
+		out, err := json.Marshal(v)
+		if err != nil {
+			return nil, nil, fmt.Errorf("toml: %w", err)
+		}
+		return out, nil, nil
+	}
+	// Indexing is slow on large configs, so it is only done for errors.
+	if !json.Valid(data) {
+		_, err := indexJSON(data)
+		p := decodeProblem(data, err)
+		return nil, nil, fmt.Errorf("line %d, column %d: %s", p.Line, p.Column, p.Message)
+	}
+	return data, func(ptr string) (int, int, bool) {
+		offsets, err := indexJSON(data)
+		if err != nil {
+			return 0, 0, false
+		}
+		off, ok := offsets[ptr]
+		if !ok {
+			return 0, 0, false
+		}
+		line, col := lineColumn(data, off)
+		return line, col, true
+	}, nil
+}
+
+// yamlLocator finds values in a parsed YAML document. Mapping entries point
+// at their key.
+func yamlLocator(doc *yaml.Node) sourceLocator {
+	return func(ptr string) (int, int, bool) {
+		n := doc
+		if n.Kind == yaml.DocumentNode && len(n.Content) > 0 {
+			n = n.Content[0]
+		}
+		var key *yaml.Node
+		for _, seg := range strings.Split(ptr, "/")[1:] {
+			seg = strings.NewReplacer("~1", "/", "~0", "~").Replace(seg)
+			var next *yaml.Node
+			key = nil
+			switch n.Kind {
+			case yaml.MappingNode:
+				for i := 0; i+1 < len(n.Content); i += 2 {
+					if n.Content[i].Value == seg {
+						key, next = n.Content[i], n.Content[i+1]
+						break
+					}
+				}
+			case yaml.SequenceNode:
+				if i, err := strconv.Atoi(seg); err == nil && i < len(n.Content) {
+					next = n.Content[i]
+				}
+			}
+			if next == nil {
+				return 0, 0, false
+			}
+			n = next
+		}
+		if key != nil {
+			n = key
+		}
+		return n.Line, n.Column, true
+	}
+}
+
+// decodeConfig decodes config data in the format of path. Errors carry the
+// line in the original file where the format allows it.
+func decodeConfig(path string, data []byte) (Config, error) {
+	jsonData, locate, err := configToJSON(data, configFormat(path))
+	if err != nil {
+		return Config{}, fmt.Errorf("parse config: %w", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(jsonData, &cfg); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			ptr := typeErrorPointer(typeErr)
+			if locate != nil {
+				if line, col, ok := locate(ptr); ok {
+					return Config{}, fmt.Errorf("parse config: line %d, column %d: %s has the wrong type: expected %s, got %s", line, col, typeErr.Field, typeErr.Type, typeErr.Value)
+				}
+			}
+			return Config{}, fmt.Errorf("parse config: %s has the wrong type: expected %s, got %s", typeErr.Field, typeErr.Type, typeErr.Value)
+		}
+		return Config{}, fmt.Errorf("parse config: %w", err)
+	}
+	return cfg, nil
+}
+
+// typeErrorPointer returns the JSON pointer of the field a type error is
+// about, e.g. /menuItems/0/kinds for menuItems.0.kinds.
+func typeErrorPointer(err *json.UnmarshalTypeError) string {
+	if err.Field == "" {
+		return ""
+	}
+	return "/" + strings.ReplaceAll(err.Field, ".", "/")
+}
+
+// configEnv names the environment variable that points at a config file.
+const configEnv = "GO_TO_DASHBOARD_CONFIG"
+
+// executableDir returns the directory of the running binary. Tests replace
+// it.
+var executableDir = func() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return "."
+	}
+	return filepath.Dir(exe)
+}
+
+// configDir is a directory searched for a config file. source describes it
+// for --print-config-path.
+type configDir struct {
+	path, source string
+}
+
+// configDirs returns the directories searched for a config file, in order of
+// precedence.
+func configDirs() []configDir {
+	var dirs []configDir
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	home, _ := os.UserHomeDir()
+	if xdg == "" && home != "" {
+		xdg = filepath.Join(home, ".config")
+	}
+	if xdg != "" {
+		dirs = append(dirs, configDir{filepath.Join(xdg, "go-to-dashboard"), "XDG config directory"})
+	}
+	if home != "" {
+		dirs = append(dirs, configDir{filepath.Join(home, ".config", "k9s", "go-to-dashboard"), "k9s config directory"})
+	}
+	return append(dirs, configDir{executableDir(), "executable directory"})
+}
+
+// findConfig picks the config file: the -config flag, then $GO_TO_DASHBOARD_CONFIG,
+// then the first of configNames found in configDirs. An explicit path is
+// returned even if it doesn't exist, so loading it reports the error. With
+// no config anywhere, it returns config.json in the executable directory.
+// source says where the path came from.
+func findConfig(flagPath string) (path, source string) {
+	if flagPath != "" {
+		return flagPath, "-config flag"
+	}
+	if env := os.Getenv(configEnv); env != "" {
+		return env, "$" + configEnv
+	}
+	dirs := configDirs()
+	for _, dir := range dirs {
+		for _, name := range configNames {
+			path := filepath.Join(dir.path, name)
+			if _, err := os.Stat(path); err == nil {
+				return path, dir.source
+			}
+		}
+	}
+	last := dirs[len(dirs)-1]
+	return filepath.Join(last.path, configNames[0]), last.source + " (not found)"
+}
+
+// loadLayers decodes the config at path and the files it includes. Included
+// files are layered in order below the including file: each layer's menu
+// items are merged over the previous ones with mergeMenuItems, its vars and
+// sets override theirs by name, and its profiles come after theirs. stack
+// holds the files being loaded, to report include cycles.
+func loadLayers(path string, stack []string) (Config, error) {
+	abs := path
+	if !isConfigURL(path) {
+		var err error
+		if abs, err = filepath.Abs(path); err != nil {
+			return Config{}, fmt.Errorf("config: %w", err)
+		}
+	}
+	for _, p := range stack {
+		if p == abs {
+			return Config{}, fmt.Errorf("config: include cycle: %s -> %s", strings.Join(stack, " -> "), abs)
+		}
+	}
+	data, err := readConfigSource(path, len(stack) > 0)
+	if err != nil {
+		if isConfigURL(path) {
+			return Config{}, fmt.Errorf("read config: %s: %w", path, err)
+		}
+		return Config{}, fmt.Errorf("read config: %w", err)
+	}
+	cfg, err := decodeConfig(path, data)
+	if err != nil {
+		if len(stack) > 0 {
+			return Config{}, fmt.Errorf("%s: %w", path, err)
+		}
+		return Config{}, err
+	}
+	for i := range cfg.MenuItems {
+		cfg.MenuItems[i].source, cfg.MenuItems[i].sourceIndex = path, i
+	}
+
+	var items []MenuItem
+	var profiles []Profile
+	var base Config
+	for _, pattern := range cfg.Include {
+		var files []string
+		switch {
+		case isConfigURL(path):
+			var file string
+			file, err = resolveConfigURL(path, pattern)
+			files = []string{file}
+		case isConfigURL(pattern):
+			files = []string{pattern}
+		default:
+			files, err = expandInclude(filepath.Dir(path), pattern)
+		}
+		if err != nil {
+			return Config{}, fmt.Errorf("config: %s: include %q: %w", path, pattern, err)
+		}
+		for _, file := range files {
+			inc, err := loadLayers(file, append(stack, abs))
+			if err != nil {
+				return Config{}, err
+			}
+			items = mergeMenuItems(items, inc.MenuItems)
+			profiles = append(profiles, inc.Profiles...)
+			base.Vars = overlay(base.Vars, inc.Vars)
+			base.ConditionSets = overlay(base.ConditionSets, inc.ConditionSets)
+			base.TemplateVarSets = overlay(base.TemplateVarSets, inc.TemplateVarSets)
+		}
+	}
+	cfg.Vars = overlay(base.Vars, cfg.Vars)
+	cfg.ConditionSets = overlay(base.ConditionSets, cfg.ConditionSets)
+	cfg.TemplateVarSets = overlay(base.TemplateVarSets, cfg.TemplateVarSets)
+	cfg.MenuItems = mergeMenuItems(items, cfg.MenuItems)
+	cfg.Profiles = append(profiles, cfg.Profiles...)
+	return cfg, nil
+}
+
+// expandInclude resolves an include entry relative to dir. A leading ~/ is
+// the home directory. Globs may match nothing; plain paths must exist.
+func expandInclude(dir, pattern string) ([]string, error) {
+	if rest, ok := strings.CutPrefix(pattern, "~/"); ok {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, err
+		}
+		pattern = filepath.Join(home, rest)
+	}
+	if !filepath.IsAbs(pattern) {
+		pattern = filepath.Join(dir, pattern)
+	}
+	if !strings.ContainsAny(pattern, "*?[") {
+		if _, err := os.Stat(pattern); err != nil {
+			return nil, err
+		}
+		return []string{pattern}, nil
+	}
+	return filepath.Glob(pattern)
+}
+
+// mergeMenuItems layers items over base. An item whose id is already in base
+// replaces that item in place, or removes it if the item is disabled. Other
+// items are appended; disabled ones are dropped.
+func mergeMenuItems(base, items []MenuItem) []MenuItem {
+	merged := append([]MenuItem(nil), base...)
+	for _, item := range items {
+		if item.ID != "" {
+			if i := indexOfID(merged, item.ID); i >= 0 {
+				if item.Disabled {
+					merged = append(merged[:i], merged[i+1:]...)
+				} else {
+					merged[i] = item
+				}
+				continue
+			}
+		}
+		if !item.Disabled {
+			merged = append(merged, item)
+		}
+	}
+	return merged
+}
+
+// overlay returns base with the entries of top added or replaced. base may
+// be modified.
+func overlay[V any](base, top map[string]V) map[string]V {
+	if len(base) == 0 {
+		return top
+	}
+	for k, v := range top {
+		base[k] = v
+	}
+	return base
+}
+
+func indexOfID(items []MenuItem, id string) int {
+	for i := range items {
+		if items[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,331 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ItemTrace explains whether a menu item is shown for a resource.
+type ItemTrace struct {
+	Title           string       `json:"title"`
+	Shown           bool         `json:"shown"`
+	Kinds           []string     `json:"kinds,omitempty"`
+	KindMatch       bool         `json:"kindMatch"`
+	MissingRequired []string     `json:"missingRequired,omitempty"`
+	TemplateError   string       `json:"templateError,omitempty"`
+	Filters         *FilterTrace `json:"filters,omitempty"`
+	URL             string       `json:"url,omitempty"`
+}
+
+// FilterTrace is the evaluation of one filter group.
+type FilterTrace struct {
+	Passed     bool             `json:"passed"`
+	Conditions []ConditionTrace `json:"conditions,omitempty"`
+	Expression *ExpressionTrace `json:"expression,omitempty"`
+	AllOf      []FilterTrace    `json:"allOf,omitempty"`
+	AnyOf      []FilterTrace    `json:"anyOf,omitempty"`
+	Not        *FilterTrace     `json:"not,omitempty"`
+}
+
+// ConditionTrace is the evaluation of one condition: what the path resolved
+// to, what it was compared against and the result before and after invert.
+type ConditionTrace struct {
+	Path          string      `json:"path"`
+	Found         bool        `json:"found"`
+	Value         interface{} `json:"value,omitempty"`
+	KeyRegex      string      `json:"keyRegex,omitempty"`
+	ValueRegex    string      `json:"valueRegex,omitempty"`
+	Op            string      `json:"op,omitempty"`
+	Operand       interface{} `json:"operand,omitempty"`
+	ValueFromPath string      `json:"valueFromPath,omitempty"`
+	MatchedEntry  string      `json:"matchedEntry,omitempty"`
+	Matched       bool        `json:"matched"`
+	Invert        bool        `json:"invert,omitempty"`
+	Result        bool        `json:"result"`
+}
+
+// ExpressionTrace is the evaluation of a filter expression.
+type ExpressionTrace struct {
+	Expression string `json:"expression"`
+	Result     bool   `json:"result"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Explain evaluates the item against the resource like FilterMenuItems, but
+// records every step instead of stopping at the first failure.
+func (item MenuItem) Explain(r *Resource) ItemTrace {
+	t := ItemTrace{
+		Title:           item.Title,
+		Kinds:           item.Kinds,
+		KindMatch:       item.AppliesToKind(r.Kind),
+		MissingRequired: item.MissingRequired(r),
+	}
+	if err := item.TemplateError(r); err != nil {
+		t.TemplateError = err.Error()
+	}
+	t.Shown = t.KindMatch && len(t.MissingRequired) == 0 && t.TemplateError == ""
+	if !item.Filters.IsEmpty() {
+		ft := item.Filters.Trace(r)
+		t.Filters = &ft
+		t.Shown = t.Shown && ft.Passed
+	}
+	if t.Shown {
+		t.URL = item.ResolveURL(r)
+	}
+	return t
+}
+
+// Trace is the tracing variant of ItemFilters.Evaluate.
+func (f *ItemFilters) Trace(r *Resource) FilterTrace {
+	t := FilterTrace{Passed: true}
+	for i := range f.Conditions {
+		ct := f.Conditions[i].Trace(r)
+		t.Conditions = append(t.Conditions, ct)
+		t.Passed = t.Passed && ct.Result
+	}
+	if f.program != nil {
+		et := &ExpressionTrace{Expression: f.Expression}
+		matched, err := runExpression(f.program, r)
+		et.Result = matched
+		if err != nil {
+			et.Error = err.Error()
+		}
+		t.Expression = et
+		t.Passed = t.Passed && matched
+	}
+	for i := range f.AllOf {
+		sub := f.AllOf[i].Trace(r)
+		t.AllOf = append(t.AllOf, sub)
+		t.Passed = t.Passed && sub.Passed
+	}
+	if len(f.AnyOf) > 0 {
+		matched := false
+		for i := range f.AnyOf {
+			sub := f.AnyOf[i].Trace(r)
+			t.AnyOf = append(t.AnyOf, sub)
+			matched = matched || sub.Passed
+		}
+		t.Passed = t.Passed && matched
+	}
+	if f.Not != nil {
+		sub := f.Not.Trace(r)
+		t.Not = &sub
+		t.Passed = t.Passed && !sub.Passed
+	}
+	return t
+}
+
+// Trace is the tracing variant of Condition.Evaluate.
+func (c *Condition) Trace(r *Resource) ConditionTrace {
+	t := ConditionTrace{
+		Path:          c.Path,
+		Op:            c.Op,
+		Operand:       c.Value,
+		ValueFromPath: c.ValueFromPath,
+		Invert:        c.Invert,
+	}
+	if c.keyRe != nil {
+		t.KeyRegex = c.keyRe.String()
+	}
+	if c.Op == "" && c.valueRe != nil {
+		t.ValueRegex = c.valueRe.String()
+	}
+	val, ok := r.ResolvePath(c.Path)
+	t.Found, t.Value = ok, val
+	if ok && c.ValueFromPath != "" {
+		var bound Condition
+		bound, t.Operand, ok = c.bindOperand(r)
+		c = &bound
+	}
+	if ok {
+		t.MatchedEntry, t.Matched = c.matchValue(val)
+	}
+	t.Result = t.Matched != c.Invert
+	return t
+}
+
+// runExplain implements the explain subcommand.
+func runExplain(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	kind := fs.String("kind", "pod", "resource kind")
+	name := fs.String("name", "", "resource name")
+	namespace := fs.String("namespace", "", "namespace")
+	kubeCtx := fs.String("context", "", "kubeconfig context; default: the current context")
+	cluster := fs.String("cluster", "", "cluster name; default: the context's cluster")
+	title := fs.String("title", "", "only explain items whose title contains this text")
+	asJSON := fs.Bool("json", false, "print JSON instead of text")
+	configPath := fs.String("config", "", "config file; overrides $"+configEnv)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	path, _ := findConfig(*configPath)
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+	res := NewResource(*kind, *name, *namespace)
+	if res == nil {
+		return fmt.Errorf("-name is required")
+	}
+	context, clusterName, err := kubeContext(*kubeCtx, *cluster)
+	if err != nil {
+		return err
+	}
+	var profiles []string
+	res.Context = *kubeCtx
+	res.Vars, profiles = cfg.ApplyProfiles(context, clusterName)
+	if err := res.FetchJSON(); err != nil {
+		return fmt.Errorf("kubectl get %s: %w", res.Kind, err)
+	}
+
+	var traces []ItemTrace
+	for _, item := range cfg.MenuItems {
+		if *title != "" && !strings.Contains(strings.ToLower(item.Title), strings.ToLower(*title)) {
+			continue
+		}
+		traces = append(traces, item.Explain(res))
+	}
+	if *asJSON {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(traces)
+	}
+	fmt.Fprintf(stdout, "context %s, cluster %s, profiles: %s\n\n", context, clusterName, orNone(profiles))
+	writeExplain(stdout, traces)
+	return nil
+}
+
+// writeExplain prints traces as indented text, one block per item.
+func writeExplain(w io.Writer, traces []ItemTrace) {
+	for _, t := range traces {
+		fmt.Fprintf(w, "%s %s\n", mark(t.Shown), t.Title)
+		if len(t.Kinds) > 0 {
+			fmt.Fprintf(w, "    %s kinds %s\n", mark(t.KindMatch), strings.Join(t.Kinds, ", "))
+		}
+		if len(t.MissingRequired) > 0 {
+			fmt.Fprintf(w, "    %s required templateVars empty: %s\n", mark(false), strings.Join(t.MissingRequired, ", "))
+		}
+		if t.TemplateError != "" {
+			fmt.Fprintf(w, "    %s urlTemplate: %s\n", mark(false), t.TemplateError)
+		}
+		if t.Filters != nil {
+			writeFilterTrace(w, *t.Filters, "    ")
+		}
+		if t.URL != "" {
+			fmt.Fprintf(w, "    → %s\n", t.URL)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+func writeFilterTrace(w io.Writer, t FilterTrace, indent string) {
+	for _, c := range t.Conditions {
+		fmt.Fprintf(w, "%s%s %s\n", indent, mark(c.Result), describeConditionTrace(c))
+	}
+	if e := t.Expression; e != nil {
+		fmt.Fprintf(w, "%s%s expression %s", indent, mark(e.Result), e.Expression)
+		if e.Error != "" {
+			fmt.Fprintf(w, " (error: %s)", e.Error)
+		}
+		fmt.Fprintln(w)
+	}
+	groups := []struct {
+		name   string
+		traces []FilterTrace
+	}{{"allOf", t.AllOf}, {"anyOf", t.AnyOf}}
+	for _, g := range groups {
+		for i, sub := range g.traces {
+			fmt.Fprintf(w, "%s%s %s[%d]\n", indent, mark(sub.Passed), g.name, i)
+			writeFilterTrace(w, sub, indent+"    ")
+		}
+	}
+	if t.Not != nil {
+		fmt.Fprintf(w, "%s%s not (inner group %s)\n", indent, mark(!t.Not.Passed), passFail(t.Not.Passed))
+		writeFilterTrace(w, *t.Not, indent+"    ")
+	}
+}
+
+// describeConditionTrace renders a condition trace on one line, e.g.
+// `metadata.labels key ^app$ value ^nginx$: matched app=nginx`.
+func describeConditionTrace(c ConditionTrace) string {
+	var b strings.Builder
+	b.WriteString(c.Path)
+	if c.KeyRegex != "" && c.KeyRegex != anchorPattern(".*") {
+		fmt.Fprintf(&b, " key %s", c.KeyRegex)
+	}
+	switch {
+	case c.Op != "" && c.ValueFromPath != "":
+		fmt.Fprintf(&b, " %s %s (%s)", c.Op, c.ValueFromPath, compactJSON(c.Operand))
+	case c.Op != "":
+		fmt.Fprintf(&b, " %s %s", c.Op, compactJSON(c.Operand))
+	default:
+		fmt.Fprintf(&b, " value %s", c.ValueRegex)
+	}
+	b.WriteString(": ")
+	switch {
+	case !c.Found:
+		b.WriteString("path not found")
+	case c.Matched && c.MatchedEntry != "":
+		fmt.Fprintf(&b, "matched %s", c.MatchedEntry)
+	case c.Matched:
+		b.WriteString("matched")
+	default:
+		fmt.Fprintf(&b, "no match in %s", compactJSON(c.Value))
+	}
+	if c.Invert {
+		fmt.Fprintf(&b, ", inverted → %s", passFail(c.Result))
+	}
+	return b.String()
+}
+
+// compactJSON renders a value as single-line JSON, shortened for display.
+func compactJSON(v interface{}) string {
+	if v == nil {
+		return "null"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	const max = 120
+	if s := []rune(string(data)); len(s) > max {
+		return string(s[:max]) + "…"
+	}
+	return string(data)
+}
+
+func orNone(names []string) string {
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, ", ")
+}
+
+func mark(ok bool) string {
+	if ok {
+		return "✓"
+	}
+	return "✗"
+}
+
+func passFail(ok bool) string {
+	if ok {
+		return "pass"
+	}
+	return "fail"
+}
+
+// explainMain runs the explain subcommand and exits.
+func explainMain(args []string) {
+	if err := runExplain(args, os.Stdout); err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintf(os.Stderr, "explain: %v\n", err)
+		}
+		os.Exit(2)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+)
+
+// compileExpression compiles a filter expression. Resource fields aren't known
+// until runtime, so unknown names are allowed and resolve to nil. Syntax
+// errors report their line and column with a snippet of the expression.
+func compileExpression(text string) (*vm.Program, error) {
+	return expr.Compile(text, expr.AsBool(), expr.AllowUndefinedVariables())
+}
+
+// runExpression evaluates a compiled expression against the resource. Runtime
+// errors (e.g. comparing a missing field with a number) count as false.
+func runExpression(p *vm.Program, r *Resource) (bool, error) {
+	out, err := expr.Run(p, expressionEnv(r))
+	if err != nil {
+		return false, err
+	}
+	matched, _ := out.(bool)
+	return matched, nil
+}
+
+// expressionEnv exposes the resource's top-level fields (metadata, spec,
+// status, ...) plus labels and annotations as shortcuts.
+func expressionEnv(r *Resource) map[string]interface{} {
+	env := make(map[string]interface{}, len(r.Parsed)+2)
+	for k, v := range r.Parsed {
+		env[k] = v
+	}
+	for _, name := range []string{"labels", "annotations"} {
+		if _, taken := env[name]; taken {
+			continue
+		}
+		val, _ := r.ResolvePath("metadata." + name)
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			m = map[string]interface{}{}
+		}
+		env[name] = m
+	}
+	return env
+}
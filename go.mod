@@ -3,8 +3,11 @@ module go-to-dashboard
 go 1.24
 
 require (
+	github.com/BurntSushi/toml v1.6.0
+	github.com/expr-lang/expr v1.17.8
 	github.com/pkg/browser v0.0.0-20210911075715-681adbf594b8
 	golang.design/x/clipboard v0.7.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,637 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Problem is an error or warning found by lint, located in the config file.
+type Problem struct {
+	Pointer string // JSON pointer to the offending value, e.g. /menuItems/2/title
+	Offset  int    // byte offset of Pointer in the file
+	Line    int
+	Column  int
+	Warning bool
+	Message string
+}
+
+func (p Problem) String() string {
+	severity := "error"
+	if p.Warning {
+		severity = "warning"
+	}
+	if p.Line == 0 {
+		// position unknown (TOML): name the field instead
+		if p.Pointer == "" {
+			return fmt.Sprintf("%s: %s", severity, p.Message)
+		}
+		return fmt.Sprintf("%s: %s: %s", strings.TrimPrefix(p.Pointer, "/"), severity, p.Message)
+	}
+	return fmt.Sprintf("%d:%d: %s: %s", p.Line, p.Column, severity, p.Message)
+}
+
+// LintConfig checks a config file and reports every problem it finds, sorted
+// by position: JSON syntax and type errors, unknown fields, the first
+// validation error of each menu item, and warnings for configs that load but
+// probably don't do what was meant.
+func LintConfig(data []byte) []Problem {
+	offsets, err := indexJSON(data)
+	if err != nil {
+		return []Problem{decodeProblem(data, err)}
+	}
+	l := &linter{data: data, offsets: offsets}
+
+	var raw interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return []Problem{decodeProblem(data, err)}
+	}
+	l.unknownFields(raw, reflect.TypeOf(Config{}), "")
+
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return append(l.problems, decodeProblem(data, err))
+	}
+	if len(cfg.MenuItems) == 0 && len(cfg.Include) == 0 {
+		l.errorf("", "no menu items")
+	}
+	for i, pattern := range cfg.Include {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			l.errorf(fmt.Sprintf("/include/%d", i), "include %q: %v", pattern, err)
+		}
+	}
+	vars, err := resolveConfigVars(cfg.Vars, false)
+	if err != nil {
+		l.errorf("/vars", "%s", strings.TrimPrefix(err.Error(), "config: "))
+	}
+	// vars and profiles may also come from an include, and the environment
+	// is the one the config runs with, not lint's
+	lookup := varLookup(vars, err == nil && len(cfg.Include) == 0, false)
+	var globals map[string]bool
+	if len(cfg.Include) == 0 {
+		globals = cfg.globalPlaceholders()
+	}
+	for i := range cfg.Profiles {
+		if err := validateProfile(i, &cfg.Profiles[i], lookup); err != nil {
+			l.errorf(fmt.Sprintf("/profiles/%d", i), "%s", strings.TrimPrefix(err.Error(), "config: "))
+		}
+	}
+	titles := map[string]int{}
+	ids := map[string]int{}
+	for i := range cfg.MenuItems {
+		item := &cfg.MenuItems[i]
+		ptr := fmt.Sprintf("/menuItems/%d", i)
+		if item.ID != "" {
+			if first, ok := ids[item.ID]; ok {
+				l.warnf(ptr+"/id", "duplicate id %q (also menuItems[%d]); the later item replaces the earlier one", item.ID, first)
+			} else {
+				ids[item.ID] = i
+			}
+		}
+		if item.Disabled {
+			// only removes an item from an included file
+			if item.ID == "" {
+				l.warnf(ptr+"/disabled", "disabled item has no id, so it disables nothing")
+			}
+			continue
+		}
+		condPtrs, varPtrs := l.usePointers(&cfg, item, ptr)
+		if err := cfg.applyUses(i, item, len(cfg.Include) == 0); err != nil {
+			l.errorf(ptr+"/use", "%s", strings.TrimPrefix(err.Error(), "config: "))
+		} else if err := expandItemVars(i, item, lookup); err != nil {
+			l.errorf(ptr, "%s", strings.TrimPrefix(err.Error(), "config: "))
+		} else if err := validateMenuItem(i, item, globals); err != nil {
+			l.errorf(ptr, "%s", strings.TrimPrefix(err.Error(), "config: "))
+		}
+		if first, ok := titles[item.Title]; ok && item.Title != "" {
+			l.warnf(ptr+"/title", "duplicate title %q (also menuItems[%d])", item.Title, first)
+		} else {
+			titles[item.Title] = i
+		}
+		l.checkBaseURL(item, ptr)
+		l.checkFilters(&item.Filters, ptr+"/filters", condPtrs)
+		l.checkTemplateVars(item.TemplateVars, varPtrs)
+	}
+	// the items a profile overrides may come from an include
+	if len(cfg.Include) == 0 {
+		for i, p := range cfg.Profiles {
+			for _, id := range sortedKeys(p.URLs) {
+				if err := cfg.checkProfileURL(id); err != nil {
+					l.errorf(fmt.Sprintf("/profiles/%d/urls/%s", i, escapePointer(id)), "profiles[%d] (%s) urls.%s: %v", i, p.Name, id, err)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(l.problems, func(a, b int) bool {
+		return l.problems[a].Offset < l.problems[b].Offset
+	})
+	return l.problems
+}
+
+type linter struct {
+	data     []byte
+	offsets  map[string]int
+	problems []Problem
+}
+
+func (l *linter) errorf(ptr, format string, args ...interface{}) {
+	l.add(ptr, false, fmt.Sprintf(format, args...))
+}
+
+func (l *linter) warnf(ptr, format string, args ...interface{}) {
+	l.add(ptr, true, fmt.Sprintf(format, args...))
+}
+
+// add records a problem at ptr, or at its closest parent present in the file
+// (e.g. for defaults that aren't written out).
+func (l *linter) add(ptr string, warning bool, msg string) {
+	p := ptr
+	off, ok := l.offsets[p]
+	for !ok && p != "" {
+		p = p[:strings.LastIndexByte(p, '/')]
+		off, ok = l.offsets[p]
+	}
+	for _, q := range l.problems {
+		if q.Pointer == ptr && q.Message == msg {
+			return // e.g. a set used by several items
+		}
+	}
+	line, col := lineColumn(l.data, off)
+	l.problems = append(l.problems, Problem{Pointer: ptr, Offset: off, Line: line, Column: col, Warning: warning, Message: msg})
+}
+
+// unknownFields reports object keys that don't correspond to a field of t.
+// encoding/json would silently ignore them.
+func (l *linter) unknownFields(val interface{}, t reflect.Type, ptr string) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			return
+		}
+		fields := jsonFields(t)
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			child := ptr + "/" + escapePointer(k)
+			if ft, ok := fields[k]; ok {
+				l.unknownFields(m[k], ft, child)
+				continue
+			}
+			if name, ok := foldField(fields, k); ok {
+				l.warnf(child, "field %q only matches %q case-insensitively", k, name)
+				l.unknownFields(m[k], fields[name], child)
+				continue
+			}
+			if name, ok := closestField(fields, k); ok {
+				l.errorf(child, "unknown field %q (did you mean %q?)", k, name)
+			} else {
+				l.errorf(child, "unknown field %q", k)
+			}
+		}
+	case reflect.Slice:
+		list, ok := val.([]interface{})
+		if !ok {
+			return
+		}
+		for i, elem := range list {
+			l.unknownFields(elem, t.Elem(), ptr+"/"+strconv.Itoa(i))
+		}
+	case reflect.Map:
+		m, ok := val.(map[string]interface{})
+		if !ok {
+			return
+		}
+		for k, elem := range m {
+			l.unknownFields(elem, t.Elem(), ptr+"/"+escapePointer(k))
+		}
+	}
+}
+
+// jsonFields maps the JSON names of t's exported fields to their types.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+func foldField(fields map[string]reflect.Type, key string) (string, bool) {
+	for name := range fields {
+		if strings.EqualFold(name, key) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// closestField suggests the field name nearest to a misspelled key.
+func closestField(fields map[string]reflect.Type, key string) (string, bool) {
+	best, bestDist := "", 3
+	for name := range fields {
+		if d := editDistance(strings.ToLower(name), strings.ToLower(key)); d < bestDist || (d == bestDist && name < best) {
+			best, bestDist = name, d
+		}
+	}
+	return best, best != ""
+}
+
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur := make([]int, len(b)+1)
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev = cur
+	}
+	return prev[len(b)]
+}
+
+// checkBaseURL warns about urls that browsers can't open.
+func (l *linter) checkBaseURL(item *MenuItem, ptr string) {
+	if item.URL == "" || strings.Contains(item.URL, "${") {
+		return
+	}
+	u, err := url.Parse(item.URL)
+	switch {
+	case err != nil:
+		l.warnf(ptr+"/url", "invalid url %q: %v", item.URL, err)
+	case !u.IsAbs():
+		l.warnf(ptr+"/url", "url %q has no scheme", item.URL)
+	case (u.Scheme == "http" || u.Scheme == "https") && u.Host == "":
+		l.warnf(ptr+"/url", "url %q has no host", item.URL)
+	}
+}
+
+// usePointers returns the pointers of an item's conditions and templateVars
+// once applyUses has added the ones from sets: those point into the set
+// definitions, so problems in a set are reported there.
+func (l *linter) usePointers(cfg *Config, item *MenuItem, ptr string) (conds, vars []string) {
+	for _, name := range item.Use {
+		for k := range cfg.ConditionSets[name] {
+			conds = append(conds, fmt.Sprintf("/conditionSets/%s/%d", escapePointer(name), k))
+		}
+		for k := range cfg.TemplateVarSets[name] {
+			vars = append(vars, fmt.Sprintf("/templateVarSets/%s/%d", escapePointer(name), k))
+		}
+	}
+	for k := range item.Filters.Conditions {
+		conds = append(conds, fmt.Sprintf("%s/filters/conditions/%d", ptr, k))
+	}
+	for k := range item.TemplateVars {
+		vars = append(vars, fmt.Sprintf("%s/templateVars/%d", ptr, k))
+	}
+	return conds, vars
+}
+
+// checkFilters warns about redundant anchors and conditions that contradict
+// another condition of the same group. condPtrs are the pointers of
+// f.Conditions; nil means ptr/conditions/N.
+func (l *linter) checkFilters(f *ItemFilters, ptr string, condPtrs []string) {
+	for k := range f.Conditions {
+		c := &f.Conditions[k]
+		cptr := fmt.Sprintf("%s/conditions/%d", ptr, k)
+		if condPtrs != nil {
+			cptr = condPtrs[k]
+		}
+		for _, p := range []struct{ field, pattern string }{{"keyPattern", c.KeyPattern}, {"valuePattern", c.ValuePattern}} {
+			if isAnchored(p.pattern) {
+				l.warnf(cptr+"/"+p.field, "%s %q is anchored twice; patterns are implicitly anchored with ^...$", p.field, p.pattern)
+			}
+		}
+		for j := 0; j < k; j++ {
+			if why, ok := contradicts(&f.Conditions[j], c); ok {
+				l.warnf(cptr, "unreachable: contradicts conditions[%d] (%s), so this group never passes", j, why)
+				break
+			}
+		}
+	}
+	for i := range f.AllOf {
+		l.checkFilters(&f.AllOf[i], fmt.Sprintf("%s/allOf/%d", ptr, i), nil)
+	}
+	for i := range f.AnyOf {
+		l.checkFilters(&f.AnyOf[i], fmt.Sprintf("%s/anyOf/%d", ptr, i), nil)
+	}
+	if f.Not != nil {
+		l.checkFilters(f.Not, ptr+"/not", nil)
+	}
+}
+
+func isAnchored(p string) bool {
+	return strings.HasPrefix(p, "^") || (strings.HasSuffix(p, "$") && !strings.HasSuffix(p, `\$`))
+}
+
+// contradicts reports whether two conditions of one group can never both
+// match: one is the inverse of the other, or they require different literal
+// values of the same scalar field (see scalarCondition).
+func contradicts(a, b *Condition) (string, bool) {
+	if a.Path != b.Path || a.KeyPattern != b.KeyPattern || a.ValueFromPath != "" || b.ValueFromPath != "" {
+		return "", false
+	}
+	va, litA := literalValue(a)
+	vb, litB := literalValue(b)
+	if a.Op == b.Op && a.ValuePattern == b.ValuePattern && va == vb && litA == litB && a.Invert != b.Invert {
+		return "one is the inverse of the other", true
+	}
+	if a.Invert || b.Invert || !litA || !litB || va == vb || !scalarCondition(a) {
+		return "", false
+	}
+	return fmt.Sprintf("a value can't be both %q and %q", va, vb), true
+}
+
+// scalarFields are resource fields Kubernetes defines as single strings.
+var scalarFields = map[string]bool{
+	"metadata.name":      true,
+	"metadata.namespace": true,
+	"metadata.uid":       true,
+	"spec.nodeName":      true,
+	"spec.type":          true,
+	"status.phase":       true,
+	"status.podIP":       true,
+	"status.hostIP":      true,
+}
+
+// scalarCondition reports whether c compares a single string value. A map or
+// array matches if any entry does, so two different values can both match
+// (labels app=nginx and env=production, or finalizers a and b). Without a
+// resource lint can't tell what a path holds, so it only trusts label and
+// annotation values (a path to one, or the map with a literal keyPattern)
+// and scalarFields.
+func scalarCondition(c *Condition) bool {
+	segs, err := parsePath(c.Path)
+	if err != nil {
+		return false
+	}
+	keys := make([]string, len(segs))
+	for i, seg := range segs {
+		if seg.isWildcard() {
+			return false
+		}
+		keys[i] = seg.key
+	}
+	defaultKey := c.KeyPattern == "" || c.KeyPattern == ".*"
+	if len(keys) >= 2 && keys[0] == "metadata" && (keys[1] == "labels" || keys[1] == "annotations") {
+		switch len(keys) {
+		case 2:
+			return !defaultKey && isLiteral(c.KeyPattern)
+		case 3:
+			return defaultKey
+		}
+		return false
+	}
+	return defaultKey && scalarFields[strings.Join(keys, ".")]
+}
+
+// literalValue returns the single value a condition accepts, if it is a plain
+// string pattern or an eq with a string.
+func literalValue(c *Condition) (string, bool) {
+	if c.Op == "eq" {
+		s, ok := c.Value.(string)
+		return s, ok
+	}
+	if c.Op == "" && isLiteral(c.ValuePattern) {
+		return c.ValuePattern, true
+	}
+	return "", false
+}
+
+func isLiteral(p string) bool {
+	return p != "" && regexp.QuoteMeta(p) == p
+}
+
+// checkTemplateVars warns about vars whose value is never inserted.
+// ptrs are the pointers of vars.
+func (l *linter) checkTemplateVars(vars []TemplateVar, ptrs []string) {
+	for j, tv := range vars {
+		own := map[string]bool{defaultPlaceholder: true}
+		if tv.Placeholder != "" {
+			own[tv.Placeholder] = true
+		}
+		used := false
+		for k, other := range vars {
+			for _, ref := range placeholderRe.FindAllString(other.URLAppend, -1) {
+				if (k == j && own[ref]) || (k != j && ref == tv.Placeholder && ref != "") {
+					used = true
+				}
+			}
+		}
+		if used {
+			continue
+		}
+		vptr := ptrs[j]
+		if tv.URLAppend != "" {
+			l.warnf(vptr+"/urlAppend", "urlAppend %q doesn't contain %s, so the value of %s is never inserted", tv.URLAppend, placeholderName(tv), tv.displayPath())
+		} else if tv.Placeholder != "" {
+			l.warnf(vptr+"/placeholder", "placeholder %s is never used", tv.Placeholder)
+		}
+	}
+}
+
+func placeholderName(tv TemplateVar) string {
+	if tv.Placeholder != "" {
+		return defaultPlaceholder + " or " + tv.Placeholder
+	}
+	return defaultPlaceholder
+}
+
+// indexJSON maps the JSON pointer of every value in data to its byte offset.
+// Object members point at their key.
+func indexJSON(data []byte) (map[string]int, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	offsets := map[string]int{}
+	var walk func(ptr string) error
+	walk = func(ptr string) error {
+		offsets[ptr] = skipSpace(data, int(dec.InputOffset()))
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'):
+			for dec.More() {
+				keyOff := skipSpace(data, int(dec.InputOffset()))
+				key, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				child := ptr + "/" + escapePointer(key.(string))
+				if err := walk(child); err != nil {
+					return err
+				}
+				offsets[child] = keyOff
+			}
+			_, err = dec.Token()
+		case json.Delim('['):
+			for i := 0; dec.More(); i++ {
+				if err := walk(ptr + "/" + strconv.Itoa(i)); err != nil {
+					return err
+				}
+			}
+			_, err = dec.Token()
+		}
+		return err
+	}
+	if err := walk(""); err != nil {
+		return nil, err
+	}
+	if off := skipSpace(data, int(dec.InputOffset())); off < len(data) {
+		return nil, trailingDataError(off)
+	}
+	return offsets, nil
+}
+
+// trailingDataError is the offset of content after the top-level value.
+type trailingDataError int
+
+func (e trailingDataError) Error() string {
+	return "unexpected content after the top-level value"
+}
+
+// skipSpace advances off past whitespace and the separators between tokens.
+func skipSpace(data []byte, off int) int {
+	for off < len(data) && strings.IndexByte(" \t\r\n,:", data[off]) >= 0 {
+		off++
+	}
+	return off
+}
+
+func escapePointer(key string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(key)
+}
+
+// lineColumn converts a byte offset to a 1-based line and column.
+func lineColumn(data []byte, off int) (int, int) {
+	if off > len(data) {
+		off = len(data)
+	}
+	before := data[:off]
+	line := bytes.Count(before, []byte("\n")) + 1
+	col := len([]rune(string(before[bytes.LastIndexByte(before, '\n')+1:]))) + 1
+	return line, col
+}
+
+// decodeProblem locates a JSON syntax or type error. Type errors also get
+// the pointer of their field, so lintFile can locate them in YAML.
+func decodeProblem(data []byte, err error) Problem {
+	off := 0
+	ptr := ""
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	var trailing trailingDataError
+	switch {
+	case errors.As(err, &syntaxErr):
+		off = int(syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		off = int(typeErr.Offset)
+		ptr = typeErrorPointer(typeErr)
+	case errors.As(err, &trailing):
+		off = int(trailing)
+	case err == io.EOF:
+		err = fmt.Errorf("config is empty")
+	case err == io.ErrUnexpectedEOF:
+		off = len(data)
+		err = fmt.Errorf("unexpected end of JSON input")
+	}
+	line, col := lineColumn(data, off)
+	return Problem{Pointer: ptr, Offset: off, Line: line, Column: col, Message: err.Error()}
+}
+
+// lintFile lints a config file in any supported format. YAML and TOML are
+// converted to JSON first and problems are mapped back to the original file
+// where possible.
+func lintFile(path string, data []byte) []Problem {
+	format := configFormat(path)
+	if format == "json" {
+		return LintConfig(data)
+	}
+	jsonData, locate, err := configToJSON(data, format)
+	if err != nil {
+		return []Problem{{Message: err.Error()}}
+	}
+	problems := LintConfig(jsonData)
+	for i := range problems {
+		p := &problems[i]
+		p.Offset, p.Line, p.Column = 0, 0, 0
+		for ptr := p.Pointer; locate != nil; ptr = ptr[:strings.LastIndexByte(ptr, '/')] {
+			if line, col, ok := locate(ptr); ok {
+				p.Line, p.Column = line, col
+				break
+			}
+			if ptr == "" {
+				break
+			}
+		}
+	}
+	// the JSON has its keys sorted, so restore the order of the original file
+	sort.SliceStable(problems, func(a, b int) bool {
+		pa, pb := problems[a], problems[b]
+		return pa.Line < pb.Line || pa.Line == pb.Line && pa.Column < pb.Column
+	})
+	return problems
+}
+
+// runLint implements the lint subcommand. It returns the exit status: 1 if
+// there are any errors, 2 if the config can't be read. Warnings alone exit 0.
+func runLint(args []string, stdout io.Writer) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	path, _ := findConfig(fs.Arg(0))
+	data, err := readConfigSource(path, false)
+	if err != nil {
+		fmt.Fprintf(stdout, "lint: %v\n", err)
+		return 2
+	}
+	problems := lintFile(path, data)
+	status := 0
+	for _, p := range problems {
+		if !p.Warning {
+			status = 1
+		}
+		if p.Line == 0 {
+			fmt.Fprintf(stdout, "%s: %s\n", path, p)
+		} else {
+			fmt.Fprintf(stdout, "%s:%s\n", path, p)
+		}
+	}
+	return status
+}
@@ -39,106 +39,130 @@ func colorForKey(key string) string {
 }
 
 func main() {
-	pod := flag.String("pod", "", "pod name (from k9s)")
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "explain":
+			explainMain(os.Args[2:])
+			return
+		case "lint":
+			os.Exit(runLint(os.Args[2:], os.Stdout))
+		case "schema":
+			if err := runSchema(os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "schema: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
+	kind := flag.String("kind", "pod", "resource kind, e.g. pod, deployment, node (from k9s $RESOURCE_NAME)")
+	name := flag.String("name", "", "resource name (from k9s)")
+	pod := flag.String("pod", "", "pod name (from k9s); shorthand for -kind pod -name NAME")
 	namespace := flag.String("namespace", "", "namespace (from k9s)")
-	debug := flag.Bool("debug", false, "show DEBUG option to inspect pod spec paths")
+	kubeCtx := flag.String("context", "", "kubeconfig context (from k9s $CONTEXT); default: the current context")
+	cluster := flag.String("cluster", "", "cluster name (from k9s $CLUSTER); default: the context's cluster")
+	debug := flag.Bool("debug", false, "show DEBUG option to inspect resource paths")
+	configPath := flag.String("config", "", "config file (.json, .yaml, .yml or .toml); overrides $"+configEnv)
+	printConfigPath := flag.Bool("print-config-path", false, "print the config file that would be used and exit")
 	flag.Parse()
 
-	configPath := "config.json"
-	if exe, err := os.Executable(); err == nil {
-		configPath = filepath.Join(filepath.Dir(exe), "config.json")
+	if *pod != "" {
+		*kind, *name = "pod", *pod
 	}
-	cfg, err := LoadConfig(configPath)
+
+	path, source := findConfig(*configPath)
+	if *printConfigPath {
+		fmt.Println(path)
+		fmt.Fprintf(os.Stderr, "from %s\n", source)
+		return
+	}
+	cfg, err := LoadConfig(path)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Failed to load config! %v\n", err)
 		time.Sleep(5 * time.Second)
 		os.Exit(1)
 	}
 
-	// Build pod context and fetch full JSON
-	var podErr string
-	pd := NewPodData(*pod, *namespace)
-	if pd != nil {
-		if err := pd.FetchPodJSON(); err != nil {
-			podErr = fmt.Sprintf("kubectl get pod: %v", err)
-			fmt.Fprintf(os.Stderr, "%s\n", podErr)
+	context, clusterName, err := kubeContext(*kubeCtx, *cluster)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+	}
+	vars, _ := cfg.ApplyProfiles(context, clusterName)
+
+	// Build resource context and fetch full JSON
+	var resErr string
+	res := NewResource(*kind, *name, *namespace)
+	if res != nil {
+		res.Context, res.Vars = *kubeCtx, vars
+		if err := res.FetchJSON(); err != nil {
+			resErr = fmt.Sprintf("kubectl get %s: %v", res.Kind, err)
+			fmt.Fprintf(os.Stderr, "%s\n", resErr)
 		}
 	}
 
-	// Filter menu items based on pod conditions
-	items := FilterMenuItems(cfg.MenuItems, pd)
+	// Filter menu items based on resource kind and conditions
+	items := FilterMenuItems(cfg.MenuItems, res)
 	if len(items) == 0 {
-		fmt.Fprintln(os.Stderr, "no menu items match this pod")
+		fmt.Fprintln(os.Stderr, "no menu items match this resource")
 	}
 
 	// Build fzf input: "title\tdescription\turl" — resolve templateVars into URLs
-	const debugMarker = "__DEBUG_POD_SPEC__"
+	const debugMarker = "__DEBUG_RESOURCE_SPEC__"
 	var lines []string
-	// Add DEBUG entry at the top when --debug and pod data is available
-	if *debug && pd != nil && pd.Parsed != nil {
-		lines = append(lines, "[DEBUG] Open pod spec paths in VS Code\tAll dot-notation paths and values for this pod\t"+debugMarker)
+	// Add DEBUG entry at the top when --debug and resource data is available
+	if *debug && res != nil && res.Parsed != nil {
+		lines = append(lines, "[DEBUG] Open "+res.Kind+" spec paths in VS Code\tAll dot-notation paths and values for this "+res.Kind+"\t"+debugMarker)
 	}
 	for _, it := range items {
-		url := it.ResolveURL(pd)
+		url := it.ResolveURL(res)
 		desc := it.Description
-		if pd != nil {
-			podDesc := pd.Name
-			if pd.Namespace != "" {
-				podDesc = pd.Namespace + "/" + pd.Name
-			}
-			desc = "[" + podDesc + "] " + desc
+		if res != nil {
+			desc = "[" + res.DisplayName() + "] " + desc
 		}
 		lines = append(lines, it.Title+"\t"+desc+"\t"+url)
 	}
 	input := strings.Join(lines, "\n")
 
 	header := "Open a dashboard"
-	if pd != nil {
-		if pd.Namespace != "" {
-			header = fmt.Sprintf("Open a dashboard — pod: %s (%s)", pd.Name, pd.Namespace)
+	if res != nil {
+		if res.Namespace != "" {
+			header = fmt.Sprintf("Open a dashboard — %s: %s (%s)", res.Kind, res.Name, res.Namespace)
 		} else {
-			header = fmt.Sprintf("Open a dashboard — pod: %s", pd.Name)
+			header = fmt.Sprintf("Open a dashboard — %s: %s", res.Kind, res.Name)
 		}
 	}
-	if podErr != "" {
-		header += fmt.Sprintf("\n⚠ ERROR: %s", podErr)
+	if resErr != "" {
+		header += fmt.Sprintf("\n⚠ ERROR: %s", resErr)
 	}
 
-	// Write per-item preview files showing scoped templateVars and all pod labels
+	// Write per-item preview files showing scoped templateVars and all resource labels
 	var previewDir string
 	previewCmd := `echo {2}; echo; echo "── URL ──"; echo; echo "  {3}"`
-	if pd != nil && pd.Parsed != nil {
-		podLabels := pd.Labels()
-		tmpDir, err := os.MkdirTemp("", "fzf-pod-preview-*")
+	if res != nil && res.Parsed != nil {
+		resLabels := res.Labels()
+		tmpDir, err := os.MkdirTemp("", "fzf-resource-preview-*")
 		if err == nil {
 			previewDir = tmpDir
 			defer os.RemoveAll(previewDir)
 
 			for i, it := range items {
 				// Collect resolved templateVar info
-				type tvResolved struct {
-					path, value, appended string
-				}
-				var resolved []tvResolved
-				for _, tv := range it.TemplateVars {
-					val := tv.resolve(pd)
-					if val == "" {
-						continue
-					}
-					appended := strings.ReplaceAll(tv.URLAppend, "$VALUE", val)
-					resolved = append(resolved, tvResolved{tv.Path, val, appended})
-				}
+				resolved := it.ResolveVars(res)
 
-				// Build colored URL: base URL plain, each templateVar append colored
+				// Build colored URL: base URL plain, each templateVar append colored.
+				// urlTemplate items have no separate segments to color.
 				coloredURL := "  " + it.URL
+				if it.URLTemplate != "" {
+					coloredURL = "  " + it.ResolveURL(res)
+				}
 				for _, r := range resolved {
-					color := colorForKey(r.path)
-					coloredURL += fmt.Sprintf("%s%s%s", color, r.appended, colorReset)
+					color := colorForKey(r.Path)
+					coloredURL += fmt.Sprintf("%s%s%s", color, r.Appended, colorReset)
 				}
 
 				// Sort all label keys
-				allKeys := make([]string, 0, len(podLabels))
-				for k := range podLabels {
+				allKeys := make([]string, 0, len(resLabels))
+				for k := range resLabels {
 					allKeys = append(allKeys, k)
 				}
 				sort.Strings(allKeys)
@@ -146,7 +170,7 @@ func main() {
 				var allLabelLines []string
 				for _, k := range allKeys {
 					color := colorForKey(k)
-					allLabelLines = append(allLabelLines, fmt.Sprintf("  %s%s = %s%s", color, k, podLabels[k], colorReset))
+					allLabelLines = append(allLabelLines, fmt.Sprintf("  %s%s = %s%s", color, k, resLabels[k], colorReset))
 				}
 
 				fpath := filepath.Join(previewDir, fmt.Sprintf("%d.txt", i))
@@ -160,17 +184,19 @@ func main() {
 				if len(resolved) > 0 {
 					fmt.Fprintln(f)
 					for _, r := range resolved {
-						color := colorForKey(r.path)
-						fmt.Fprintf(f, "  %s%s%s = %s\n", color, r.path, colorReset, r.value)
+						color := colorForKey(r.Path)
+						fmt.Fprintf(f, "  %s%s%s = %s\n", color, r.Path, colorReset, r.Value)
 					}
 				}
 				fmt.Fprintln(f)
-				// Pod info section
-				fmt.Fprintf(f, "── Pod Info ──\n\n")
-				podName, _ := pd.ResolvePath("metadata.name")
-				nodeName, _ := pd.ResolvePath("spec.nodeName")
-				fmt.Fprintf(f, "  %spod%s  = %s\n", colorForKey("pod"), colorReset, stringify(podName))
-				fmt.Fprintf(f, "  %snode%s = %s\n", colorForKey("node"), colorReset, stringify(nodeName))
+				// Resource info section
+				fmt.Fprintf(f, "── Resource Info ──\n\n")
+				resName, _ := res.ResolvePath("metadata.name")
+				fmt.Fprintf(f, "  %skind%s = %s\n", colorForKey("kind"), colorReset, res.Kind)
+				fmt.Fprintf(f, "  %sname%s = %s\n", colorForKey("name"), colorReset, stringify(resName))
+				if nodeName, ok := res.ResolvePath("spec.nodeName"); ok {
+					fmt.Fprintf(f, "  %snode%s = %s\n", colorForKey("node"), colorReset, stringify(nodeName))
+				}
 				for _, l := range allLabelLines {
 					fmt.Fprintln(f, l)
 				}
@@ -211,8 +237,8 @@ func main() {
 
 	url := strings.TrimSpace(parts[len(parts)-1])
 	if url == debugMarker {
-		// Pipe flattened pod paths into VS Code via stdin
-		paths := pd.FlattenPaths()
+		// Pipe flattened resource paths into VS Code via stdin
+		paths := res.FlattenPaths()
 		content := strings.Join(paths, "\n") + "\n"
 		// Copy to clipboard
 		if err := clipboard.Init(); err != nil {
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// knownOps are the values accepted for a condition's op.
+var knownOps = map[string]bool{
+	"eq": true, "ne": true, "gt": true, "lt": true, "ge": true, "le": true,
+	"in": true, "exists": true, "semverGE": true, "quantityGT": true,
+	"durationGT": true, "newerThan": true, "olderThan": true,
+}
+
+// compileOp type-checks the operand of a condition's op and returns the
+// function that compares a stringified scalar against it.
+func compileOp(op string, operand interface{}) (func(string) bool, error) {
+	if !knownOps[op] {
+		return nil, fmt.Errorf("unknown op %q", op)
+	}
+	if op == "exists" {
+		if operand != nil {
+			return nil, fmt.Errorf("op exists takes no value")
+		}
+		return func(string) bool { return true }, nil
+	}
+	if operand == nil {
+		return nil, fmt.Errorf("op %s needs a value", op)
+	}
+	switch op {
+	case "eq", "ne":
+		eq, err := equalTo(operand)
+		if err != nil {
+			return nil, fmt.Errorf("op %s: %w", op, err)
+		}
+		if op == "ne" {
+			return func(s string) bool { return !eq(s) }, nil
+		}
+		return eq, nil
+	case "in":
+		list, ok := operand.([]interface{})
+		if !ok || len(list) == 0 {
+			return nil, fmt.Errorf("op in needs a non-empty list value, got %s", describeOperand(operand))
+		}
+		eqs := make([]func(string) bool, len(list))
+		for i, elem := range list {
+			eq, err := equalTo(elem)
+			if err != nil {
+				return nil, fmt.Errorf("op in value[%d]: %w", i, err)
+			}
+			eqs[i] = eq
+		}
+		return func(s string) bool {
+			for _, eq := range eqs {
+				if eq(s) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case "gt", "lt", "ge", "le":
+		n, ok := operand.(float64)
+		if !ok {
+			return nil, fmt.Errorf("op %s needs a number value, got %s", op, describeOperand(operand))
+		}
+		cmp := compareFunc(op)
+		return func(s string) bool {
+			v, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			return err == nil && cmp(v, n)
+		}, nil
+	case "semverGE":
+		s, ok := operand.(string)
+		if !ok {
+			return nil, fmt.Errorf("op semverGE needs a version string value, got %s", describeOperand(operand))
+		}
+		min, err := parseSemver(s)
+		if err != nil {
+			return nil, fmt.Errorf("op semverGE: %w", err)
+		}
+		return func(s string) bool {
+			v, err := parseSemver(imageTag(s))
+			return err == nil && v.compare(min) >= 0
+		}, nil
+	case "quantityGT":
+		var q float64
+		switch v := operand.(type) {
+		case float64:
+			q = v
+		case string:
+			var err error
+			if q, err = parseQuantity(v); err != nil {
+				return nil, fmt.Errorf("op quantityGT: %w", err)
+			}
+		default:
+			return nil, fmt.Errorf("op quantityGT needs a quantity value like \"2Gi\", got %s", describeOperand(operand))
+		}
+		return func(s string) bool {
+			v, err := parseQuantity(s)
+			return err == nil && v > q
+		}, nil
+	case "durationGT":
+		s, ok := operand.(string)
+		if !ok {
+			return nil, fmt.Errorf("op durationGT needs a duration value like \"1h30m\", got %s", describeOperand(operand))
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("op durationGT: %w", err)
+		}
+		return func(s string) bool {
+			v, err := time.ParseDuration(strings.TrimSpace(s))
+			return err == nil && v > d
+		}, nil
+	case "newerThan", "olderThan":
+		s, ok := operand.(string)
+		if !ok {
+			return nil, fmt.Errorf("op %s needs a duration value like \"1h\", got %s", op, describeOperand(operand))
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return nil, fmt.Errorf("op %s: %w", op, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("op %s needs a positive duration, got %q", op, s)
+		}
+		newer := op == "newerThan"
+		return func(s string) bool {
+			t, err := time.Parse(time.RFC3339, strings.TrimSpace(s))
+			if err != nil {
+				return false
+			}
+			cutoff := timeNow().Add(-d)
+			if newer {
+				return t.After(cutoff)
+			}
+			return t.Before(cutoff)
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown op %q", op)
+}
+
+// operandFromValue converts a value resolved from valueFromPath into an
+// operand for op, running it through transforms first. Lists are only valid
+// for in; numeric ops parse numbers from strings such as label values, and
+// semverGE compares against an image's tag.
+func operandFromValue(op string, val interface{}, transforms []Transform) (interface{}, bool) {
+	if list, ok := val.([]interface{}); ok {
+		if op != "in" {
+			return nil, false
+		}
+		out := make([]interface{}, len(list))
+		for i, elem := range list {
+			if out[i], ok = operandFromValue("eq", elem, transforms); !ok {
+				return nil, false
+			}
+		}
+		return out, true
+	}
+	switch val.(type) {
+	case nil, map[string]interface{}:
+		return nil, false
+	}
+	if len(transforms) > 0 {
+		val = applyTransforms(transforms, stringify(val))
+	}
+	switch op {
+	case "gt", "lt", "ge", "le":
+		n, err := strconv.ParseFloat(strings.TrimSpace(stringify(val)), 64)
+		return n, err == nil
+	case "eq", "ne":
+		return val, true
+	case "in":
+		return []interface{}{val}, true
+	case "semverGE":
+		return imageTag(stringify(val)), true
+	}
+	return stringify(val), true
+}
+
+// equalTo returns a check for equality with a scalar operand. Numbers compare
+// numerically, so 3 equals "3.0"; strings and booleans compare as text.
+func equalTo(operand interface{}) (func(string) bool, error) {
+	switch v := operand.(type) {
+	case float64:
+		return func(s string) bool {
+			n, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+			return err == nil && n == v
+		}, nil
+	case string, bool:
+		want := stringify(v)
+		return func(s string) bool { return s == want }, nil
+	}
+	return nil, fmt.Errorf("value must be a string, number or boolean, got %s", describeOperand(operand))
+}
+
+func compareFunc(op string) func(a, b float64) bool {
+	switch op {
+	case "gt":
+		return func(a, b float64) bool { return a > b }
+	case "lt":
+		return func(a, b float64) bool { return a < b }
+	case "ge":
+		return func(a, b float64) bool { return a >= b }
+	}
+	return func(a, b float64) bool { return a <= b }
+}
+
+// describeOperand names the JSON type of an operand for error messages.
+func describeOperand(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("string %q", v)
+	case float64:
+		return fmt.Sprintf("number %s", stringify(v))
+	case bool:
+		return fmt.Sprintf("boolean %t", v)
+	case []interface{}:
+		return "list"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// quantitySuffixes are the Kubernetes resource quantity suffixes.
+var quantitySuffixes = map[string]float64{
+	"n": 1e-9, "u": 1e-6, "m": 1e-3,
+	"k": 1e3, "M": 1e6, "G": 1e9, "T": 1e12, "P": 1e15, "E": 1e18,
+	"Ki": 1 << 10, "Mi": 1 << 20, "Gi": 1 << 30, "Ti": 1 << 40, "Pi": 1 << 50, "Ei": 1 << 60,
+}
+
+// parseQuantity parses a Kubernetes resource quantity such as "500m", "2Gi"
+// or "1e3" into its value in base units (cores, bytes).
+func parseQuantity(s string) (float64, error) {
+	num, mult := strings.TrimSpace(s), 1.0
+	for _, n := range []int{2, 1} {
+		if len(num) <= n {
+			continue
+		}
+		if m, ok := quantitySuffixes[num[len(num)-n:]]; ok {
+			num, mult = num[:len(num)-n], m
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(num, 64)
+	if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, fmt.Errorf("invalid quantity %q", s)
+	}
+	return n * mult, nil
+}
+
+// semver is a parsed semantic version. Missing minor and patch numbers are 0.
+type semver struct {
+	nums [3]int
+	pre  []string
+}
+
+// parseSemver parses versions such as "1.4", "v1.4.2" or "1.5.0-rc.1".
+// Build metadata after + is ignored.
+func parseSemver(s string) (semver, error) {
+	var v semver
+	rest := strings.TrimPrefix(strings.TrimSpace(s), "v")
+	if i := strings.IndexByte(rest, '+'); i >= 0 {
+		rest = rest[:i]
+	}
+	if i := strings.IndexByte(rest, '-'); i >= 0 {
+		v.pre = strings.Split(rest[i+1:], ".")
+		rest = rest[:i]
+	}
+	parts := strings.Split(rest, ".")
+	if len(parts) > 3 {
+		return v, fmt.Errorf("invalid version %q", s)
+	}
+	for i, p := range parts {
+		if !isIndex(p) {
+			return v, fmt.Errorf("invalid version %q", s)
+		}
+		v.nums[i], _ = strconv.Atoi(p)
+	}
+	return v, nil
+}
+
+// compare returns -1, 0 or 1 following semver precedence: a pre-release sorts
+// before its release, and numeric identifiers compare numerically.
+func (v semver) compare(o semver) int {
+	for i := range v.nums {
+		if v.nums[i] != o.nums[i] {
+			return sign(v.nums[i] - o.nums[i])
+		}
+	}
+	switch {
+	case len(v.pre) == 0 && len(o.pre) == 0:
+		return 0
+	case len(v.pre) == 0:
+		return 1
+	case len(o.pre) == 0:
+		return -1
+	}
+	for i := 0; i < len(v.pre) && i < len(o.pre); i++ {
+		a, b := v.pre[i], o.pre[i]
+		if a == b {
+			continue
+		}
+		an, bn := isIndex(a), isIndex(b)
+		switch {
+		case an && bn:
+			x, _ := strconv.Atoi(a)
+			y, _ := strconv.Atoi(b)
+			return sign(x - y)
+		case an:
+			return -1
+		case bn:
+			return 1
+		}
+		return strings.Compare(a, b)
+	}
+	return sign(len(v.pre) - len(o.pre))
+}
+
+func sign(n int) int {
+	switch {
+	case n < 0:
+		return -1
+	case n > 0:
+		return 1
+	}
+	return 0
+}
+
+// imageTag returns the tag of a container image reference
+// ("registry:5000/app:1.4.2@sha256:..." yields "1.4.2"), or s unchanged if it
+// has no tag.
+func imageTag(s string) string {
+	if i := strings.IndexByte(s, '@'); i >= 0 {
+		s = s[:i]
+	}
+	if i := strings.LastIndexByte(s, ':'); i >= 0 && !strings.Contains(s[i:], "/") {
+		return s[i+1:]
+	}
+	return s
+}
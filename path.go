@@ -0,0 +1,230 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// pathSegment is one step of a parsed path. Quoted (or escaped) segments are
+// always literal map keys; plain ones may also be array indices or wildcards.
+type pathSegment struct {
+	key    string
+	quoted bool
+}
+
+// isWildcard reports whether the segment selects every element.
+func (s pathSegment) isWildcard() bool {
+	return !s.quoted && (s.key == "*" || s.key == "#")
+}
+
+// index returns the array index for a plain numeric segment.
+func (s pathSegment) index() (int, bool) {
+	if s.quoted || !isIndex(s.key) {
+		return 0, false
+	}
+	n, err := strconv.Atoi(s.key)
+	return n, err == nil
+}
+
+// parsePath splits a path into its segments. Segments are separated by dots;
+// keys that themselves contain dots can be written in brackets
+// (metadata.labels["app.kubernetes.io/name"]) or with escaped dots
+// (metadata.labels.app\.kubernetes\.io/name). Numeric segments index into
+// arrays (spec.containers.0.image or spec.containers[0].image) and * or #
+// select every element (spec.containers.#.image).
+func parsePath(path string) ([]pathSegment, error) {
+	if path == "" {
+		return nil, fmt.Errorf("empty path")
+	}
+	var segs []pathSegment
+	i := 0
+	for i < len(path) {
+		if path[i] == '[' {
+			seg, next, err := parseBracket(path, i)
+			if err != nil {
+				return nil, err
+			}
+			segs = append(segs, seg)
+			i = next
+		} else {
+			var b strings.Builder
+			escaped := false
+			for i < len(path) && path[i] != '.' && path[i] != '[' {
+				if path[i] == '\\' {
+					if i+1 == len(path) {
+						return nil, fmt.Errorf("path %q: trailing backslash", path)
+					}
+					escaped = true
+					i++
+				}
+				b.WriteByte(path[i])
+				i++
+			}
+			if b.Len() == 0 {
+				return nil, fmt.Errorf("path %q: empty segment at offset %d", path, i)
+			}
+			segs = append(segs, pathSegment{key: b.String(), quoted: escaped})
+		}
+		if i == len(path) {
+			break
+		}
+		switch path[i] {
+		case '.':
+			i++
+			if i == len(path) {
+				return nil, fmt.Errorf("path %q: trailing dot", path)
+			}
+		case '[':
+			// bracketed segment follows directly
+		default:
+			return nil, fmt.Errorf("path %q: unexpected %q at offset %d", path, path[i], i)
+		}
+	}
+	return segs, nil
+}
+
+// parseBracket parses a bracketed segment starting at path[start] == '[' and
+// returns it with the offset just past the closing bracket. Brackets hold
+// either a quoted key, an array index or a wildcard.
+func parseBracket(path string, start int) (pathSegment, int, error) {
+	i := start + 1
+	if end := strings.IndexByte(path[i:], ']'); end >= 0 {
+		if inner := path[i : i+end]; isIndex(inner) || inner == "*" || inner == "#" {
+			return pathSegment{key: inner}, i + end + 1, nil
+		}
+	}
+	if i == len(path) || (path[i] != '"' && path[i] != '\'') {
+		return pathSegment{}, 0, fmt.Errorf("path %q: expected quoted key, index or wildcard after '[' at offset %d", path, start)
+	}
+	quote := path[i]
+	i++
+	var b strings.Builder
+	for {
+		if i == len(path) {
+			return pathSegment{}, 0, fmt.Errorf("path %q: unterminated quoted key at offset %d", path, start)
+		}
+		c := path[i]
+		if c == quote {
+			break
+		}
+		if c == '\\' && i+1 < len(path) {
+			i++
+			c = path[i]
+		}
+		b.WriteByte(c)
+		i++
+	}
+	i++ // closing quote
+	if i == len(path) || path[i] != ']' {
+		return pathSegment{}, 0, fmt.Errorf("path %q: expected ']' at offset %d", path, i)
+	}
+	return pathSegment{key: b.String(), quoted: true}, i + 1, nil
+}
+
+// resolveSegments walks val along segs. A wildcard segment resolves the rest
+// of the path against every element (map values in key order) and returns the
+// found values as a list; lists produced by nested wildcards are flattened.
+func resolveSegments(val interface{}, segs []pathSegment) (interface{}, bool) {
+	current := val
+	for i, seg := range segs {
+		if seg.isWildcard() {
+			rest := segs[i+1:]
+			nested := hasWildcard(rest)
+			var out []interface{}
+			for _, elem := range elements(current) {
+				v, ok := resolveSegments(elem, rest)
+				if !ok || v == nil {
+					continue
+				}
+				if list, isList := v.([]interface{}); isList && nested {
+					out = append(out, list...)
+					continue
+				}
+				out = append(out, v)
+			}
+			if len(out) == 0 {
+				return nil, false
+			}
+			return out, true
+		}
+		switch v := current.(type) {
+		case map[string]interface{}:
+			next, ok := v[seg.key]
+			if !ok {
+				return nil, false
+			}
+			current = next
+		case []interface{}:
+			n, ok := seg.index()
+			if !ok || n >= len(v) {
+				return nil, false
+			}
+			current = v[n]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// elements returns the children of an array, or the values of a map sorted
+// by key. Scalars have no elements.
+func elements(val interface{}) []interface{} {
+	switch v := val.(type) {
+	case []interface{}:
+		return v
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		out := make([]interface{}, len(keys))
+		for i, k := range keys {
+			out[i] = v[k]
+		}
+		return out
+	}
+	return nil
+}
+
+func hasWildcard(segs []pathSegment) bool {
+	for _, s := range segs {
+		if s.isWildcard() {
+			return true
+		}
+	}
+	return false
+}
+
+// joinPath appends a key to a path, using bracket notation when the key
+// cannot be written as a plain dot segment. Brackets use single quotes so the
+// path can be pasted into a JSON string without escaping.
+func joinPath(prefix, key string) string {
+	if needsBrackets(key) {
+		return prefix + `['` + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(key) + `']`
+	}
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func needsBrackets(key string) bool {
+	return key == "" || key == "*" || key == "#" || isIndex(key) || strings.ContainsAny(key, `.[]\`)
+}
+
+// isIndex reports whether s is a non-empty string of ASCII digits.
+func isIndex(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// Profile adjusts the menu for the kubeconfig contexts or clusters it
+// matches: it supplies placeholder values and replaces item URLs. Every
+// matching profile applies, in order, so later profiles win.
+type Profile struct {
+	Name    string            `json:"name"`
+	Context string            `json:"context,omitempty"` // regex for the context name (implicitly anchored)
+	Cluster string            `json:"cluster,omitempty"` // regex for the cluster name (implicitly anchored)
+	Vars    map[string]string `json:"vars,omitempty"`    // placeholder values, e.g. {"ENV": "prod"} for $ENV
+	URLs    map[string]string `json:"urls,omitempty"`    // menu item id -> url
+
+	// compiled patterns (populated by ValidateConfig, not serialized)
+	contextRe *regexp.Regexp
+	clusterRe *regexp.Regexp
+}
+
+// builtinVars are the placeholders set from the kube context without a
+// profile.
+var builtinVars = []string{"CONTEXT", "CLUSTER"}
+
+// validateProfile checks and compiles the i-th profile and expands config
+// vars in its urls.
+func validateProfile(i int, p *Profile, lookup func(string) (string, error)) error {
+	if p.Name == "" {
+		return fmt.Errorf("config: profiles[%d] has empty name", i)
+	}
+	var err error
+	if p.contextRe, err = compileOptional(p.Context); err != nil {
+		return fmt.Errorf("config: profiles[%d] (%s) invalid context %q: %w", i, p.Name, p.Context, err)
+	}
+	if p.clusterRe, err = compileOptional(p.Cluster); err != nil {
+		return fmt.Errorf("config: profiles[%d] (%s) invalid cluster %q: %w", i, p.Name, p.Cluster, err)
+	}
+	for id, u := range p.URLs {
+		expanded, err := expandVarRefs(u, lookup)
+		if err != nil {
+			return fmt.Errorf("config: profiles[%d] (%s) urls.%s: %w", i, p.Name, id, err)
+		}
+		p.URLs[id] = expanded
+	}
+	for name := range p.Vars {
+		if placeholderRe.FindString("$"+name) != "$"+name {
+			return fmt.Errorf("config: profiles[%d] (%s) invalid var name %q (want A-Z, 0-9 or _, starting with a letter)", i, p.Name, name)
+		}
+		for _, b := range builtinVars {
+			if name == b {
+				return fmt.Errorf("config: profiles[%d] (%s) var %s is set from the kube context", i, p.Name, name)
+			}
+		}
+	}
+	return nil
+}
+
+// checkProfileURL reports why a profile's url override for id would have no
+// effect: no menu item has the id, or the item builds its URL from
+// urlTemplate, which the override doesn't replace.
+func (cfg *Config) checkProfileURL(id string) error {
+	j := indexOfID(cfg.MenuItems, id)
+	switch {
+	case j < 0:
+		return fmt.Errorf("no menu item has id %q", id)
+	case cfg.MenuItems[j].URLTemplate != "":
+		return fmt.Errorf("menu item %q uses urlTemplate, which a url override doesn't replace", id)
+	}
+	return nil
+}
+
+// validateProfileURLs checks every profile's url overrides with
+// checkProfileURL.
+func (cfg *Config) validateProfileURLs() error {
+	for i, p := range cfg.Profiles {
+		for _, id := range sortedKeys(p.URLs) {
+			if err := cfg.checkProfileURL(id); err != nil {
+				return fmt.Errorf("config: profiles[%d] (%s) urls.%s: %w", i, p.Name, id, err)
+			}
+		}
+	}
+	return nil
+}
+
+// compileOptional compiles an implicitly anchored pattern; "" yields nil.
+func compileOptional(pattern string) (*regexp.Regexp, error) {
+	if pattern == "" {
+		return nil, nil
+	}
+	return regexp.Compile(anchorPattern(pattern))
+}
+
+// Matches reports whether the profile applies to the context and cluster. A
+// profile without patterns applies everywhere.
+func (p *Profile) Matches(context, cluster string) bool {
+	if p.contextRe != nil && !p.contextRe.MatchString(context) {
+		return false
+	}
+	return p.clusterRe == nil || p.clusterRe.MatchString(cluster)
+}
+
+// globalPlaceholders returns the placeholders every templateVar can use:
+// $CONTEXT, $CLUSTER and the vars of any profile.
+func (cfg *Config) globalPlaceholders() map[string]bool {
+	names := map[string]bool{}
+	for _, b := range builtinVars {
+		names["$"+b] = true
+	}
+	for _, p := range cfg.Profiles {
+		for name := range p.Vars {
+			names["$"+name] = true
+		}
+	}
+	return names
+}
+
+// ApplyProfiles applies the profiles matching context and cluster: item URLs
+// are replaced by id, and the returned vars (CONTEXT, CLUSTER and the
+// profiles' vars) are meant for Resource.Vars. It also returns the names of
+// the matching profiles.
+func (cfg *Config) ApplyProfiles(context, cluster string) (map[string]string, []string) {
+	vars := map[string]string{"CONTEXT": context, "CLUSTER": cluster}
+	var names []string
+	for i := range cfg.Profiles {
+		p := &cfg.Profiles[i]
+		if !p.Matches(context, cluster) {
+			continue
+		}
+		names = append(names, p.Name)
+		for k, v := range p.Vars {
+			vars[k] = v
+		}
+		for id, u := range p.URLs {
+			if j := indexOfID(cfg.MenuItems, id); j >= 0 {
+				cfg.MenuItems[j].URL = u
+			}
+		}
+	}
+	return vars, names
+}
@@ -0,0 +1,294 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// configKeyEnv names the environment variable with the ed25519 public key
+// that remote configs must be signed with. Without it, signatures aren't
+// checked.
+const configKeyEnv = "GO_TO_DASHBOARD_CONFIG_KEY"
+
+// userCacheDir returns the base directory for cached remote configs. Tests
+// replace it.
+var userCacheDir = os.UserCacheDir
+
+// httpClient fetches remote configs. Tests replace it.
+var httpClient = &http.Client{Timeout: 5 * time.Second}
+
+// warnOutput receives warnings that don't stop the config from loading, such
+// as falling back to a cached remote config. Tests replace it.
+var warnOutput io.Writer = os.Stderr
+
+// isConfigURL reports whether a config path is a URL rather than a file.
+func isConfigURL(path string) bool {
+	for _, scheme := range []string{"https://", "http://", "file://"} {
+		if strings.HasPrefix(path, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// readConfigSource returns the contents of a config file or URL. https://
+// configs are fetched with fetchRemoteConfig; file:// configs are read from
+// disk. With $GO_TO_DASHBOARD_CONFIG_KEY set, both need a valid signature in
+// a .sig file next to them. layer reports that the config is included by
+// another one.
+func readConfigSource(path string, layer bool) ([]byte, error) {
+	if !isConfigURL(path) {
+		return os.ReadFile(path)
+	}
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := configKey()
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "https":
+		return fetchRemoteConfig(u, key, layer)
+	case "file":
+		data, err := os.ReadFile(u.Path)
+		if err != nil || key == nil {
+			return data, err
+		}
+		sig, err := os.ReadFile(u.Path + ".sig")
+		if err != nil {
+			return nil, fmt.Errorf("signature: %w", err)
+		}
+		return data, verifyConfig(key, data, sig)
+	}
+	return nil, fmt.Errorf("unsupported URL scheme %q (want https or file)", u.Scheme)
+}
+
+// configKey returns the public key from $GO_TO_DASHBOARD_CONFIG_KEY, or nil
+// if it isn't set. The key is base64, either the raw 32 bytes or DER
+// (`openssl pkey -pubin -outform DER`).
+func configKey() (ed25519.PublicKey, error) {
+	text := strings.TrimSpace(os.Getenv(configKeyEnv))
+	if text == "" {
+		return nil, nil
+	}
+	der, err := base64.StdEncoding.DecodeString(text)
+	if err != nil {
+		return nil, fmt.Errorf("$%s: %w", configKeyEnv, err)
+	}
+	if len(der) == ed25519.PublicKeySize {
+		return ed25519.PublicKey(der), nil
+	}
+	pub, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("$%s: %w", configKeyEnv, err)
+	}
+	key, ok := pub.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("$%s: not an ed25519 key", configKeyEnv)
+	}
+	return key, nil
+}
+
+// verifyConfig checks a detached signature of data: 64 raw bytes, or their
+// base64 encoding.
+func verifyConfig(key ed25519.PublicKey, data, sig []byte) error {
+	if len(sig) != ed25519.SignatureSize {
+		decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(sig)))
+		if err != nil {
+			return fmt.Errorf("signature: %w", err)
+		}
+		sig = decoded
+	}
+	if !ed25519.Verify(key, data, sig) {
+		return errors.New("signature does not match")
+	}
+	return nil
+}
+
+// remoteCache is the last good copy of a remote config.
+type remoteCache struct {
+	URL       string    `json:"url"`
+	ETag      string    `json:"etag,omitempty"`
+	Fetched   time.Time `json:"fetched"`
+	Body      []byte    `json:"body"`
+	Signature []byte    `json:"signature,omitempty"`
+}
+
+// remoteCachePath returns the cache file for a remote config URL, or "" if
+// there is no cache directory.
+func remoteCachePath(rawURL string) string {
+	dir, err := userCacheDir()
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(rawURL))
+	return filepath.Join(dir, "go-to-dashboard", "remote-"+hex.EncodeToString(sum[:8])+".json")
+}
+
+// fetchRemoteConfig downloads a config, revalidating the cached copy with its
+// ETag. If the server can't be reached, answers with an error, or serves a
+// config with a bad signature or one that doesn't load (see
+// checkRemoteConfig), the cached copy is used with a warning. Only configs
+// that pass replace the cached copy.
+func fetchRemoteConfig(u *url.URL, key ed25519.PublicKey, layer bool) ([]byte, error) {
+	cachePath := remoteCachePath(u.String())
+	var cached *remoteCache
+	if data, err := os.ReadFile(cachePath); err == nil {
+		var c remoteCache
+		if json.Unmarshal(data, &c) == nil && c.URL == u.String() {
+			cached = &c
+		}
+	}
+	// The cached copy is checked again in case the key changed.
+	useCache := func(reason error) ([]byte, error) {
+		if cached == nil {
+			return nil, reason
+		}
+		if key != nil {
+			if err := verifyConfig(key, cached.Body, cached.Signature); err != nil {
+				return nil, fmt.Errorf("%w (cached copy: %v)", reason, err)
+			}
+		}
+		if reason != nil {
+			fmt.Fprintf(warnOutput, "config: %s: %v; using the copy cached %s\n", u, reason, cached.Fetched.Format(time.RFC3339))
+		}
+		return cached.Body, nil
+	}
+
+	etag := ""
+	if cached != nil {
+		etag = cached.ETag
+	}
+	body, newETag, err := httpGet(u, etag)
+	if err != nil {
+		return useCache(err)
+	}
+	if body == nil { // 304 Not Modified
+		return useCache(nil)
+	}
+	fresh := remoteCache{URL: u.String(), ETag: newETag, Fetched: timeNow(), Body: body}
+	if key != nil {
+		sigURL := *u
+		sigURL.Path += ".sig"
+		sig, _, err := httpGet(&sigURL, "")
+		if err == nil {
+			err = verifyConfig(key, body, sig)
+		}
+		if err != nil {
+			return useCache(fmt.Errorf("signature: %w", err))
+		}
+		fresh.Signature = sig
+	}
+	if err := checkRemoteConfig(u, body, layer); err != nil {
+		return useCache(err)
+	}
+	if cachePath != "" {
+		if data, err := json.Marshal(fresh); err == nil {
+			writeFileAtomic(cachePath, data)
+		}
+	}
+	return body, nil
+}
+
+// checkRemoteConfig decodes a fetched config and validates it. A layer or a
+// config with includes is only decoded: a layer may hold just vars, or use
+// vars and sets from the file including it, and a config with includes may
+// use theirs. LoadConfig validates the merged config.
+func checkRemoteConfig(u *url.URL, body []byte, layer bool) error {
+	cfg, err := decodeConfig(u.String(), body)
+	if err != nil || layer || len(cfg.Include) > 0 {
+		return err
+	}
+	return ValidateConfig(&cfg)
+}
+
+// writeFileAtomic writes data to a temporary file and renames it to path,
+// so a concurrent run never reads half a file. It creates path's directory.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// httpGet fetches u. With etag, a 304 response returns a nil body.
+func httpGet(u *url.URL, etag string) (body []byte, newETag string, err error) {
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, "", err
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotModified && etag != "":
+		return nil, etag, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, "", fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	body, err = io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	if body == nil {
+		body = []byte{}
+	}
+	return body, resp.Header.Get("ETag"), nil
+}
+
+// resolveConfigURL resolves an include of a remote config against its URL.
+// Globs and ~/ only make sense on the local disk, and a config from the
+// network can't include local files.
+func resolveConfigURL(base, ref string) (string, error) {
+	if strings.ContainsAny(ref, "*?[") || strings.HasPrefix(ref, "~/") {
+		return "", errors.New("globs and ~/ can't be used in a config loaded from a URL")
+	}
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	r, err := url.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	resolved := b.ResolveReference(r)
+	if resolved.Scheme != b.Scheme {
+		return "", fmt.Errorf("a %s config can't include %s URLs", b.Scheme, resolved.Scheme)
+	}
+	return resolved.String(), nil
+}
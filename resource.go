@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+// builtinKinds lists the built-in resources with the plural and short names
+// k9s and kubectl use for them.
+var builtinKinds = []struct {
+	kind, plural string
+	short        []string
+}{
+	{"pod", "pods", []string{"po"}},
+	{"deployment", "deployments", []string{"deploy"}},
+	{"statefulset", "statefulsets", []string{"sts"}},
+	{"daemonset", "daemonsets", []string{"ds"}},
+	{"replicaset", "replicasets", []string{"rs"}},
+	{"job", "jobs", nil},
+	{"cronjob", "cronjobs", []string{"cj"}},
+	{"horizontalpodautoscaler", "horizontalpodautoscalers", []string{"hpa"}},
+	{"node", "nodes", []string{"no"}},
+	{"namespace", "namespaces", []string{"ns"}},
+	{"service", "services", []string{"svc"}},
+	{"endpoints", "endpoints", []string{"ep"}},
+	{"ingress", "ingresses", []string{"ing"}},
+	{"configmap", "configmaps", []string{"cm"}},
+	{"secret", "secrets", nil},
+	{"serviceaccount", "serviceaccounts", []string{"sa"}},
+	{"persistentvolume", "persistentvolumes", []string{"pv"}},
+	{"persistentvolumeclaim", "persistentvolumeclaims", []string{"pvc"}},
+	{"storageclass", "storageclasses", []string{"sc"}},
+	{"event", "events", []string{"ev"}},
+	{"networkpolicy", "networkpolicies", []string{"netpol"}},
+	{"poddisruptionbudget", "poddisruptionbudgets", []string{"pdb"}},
+	{"customresourcedefinition", "customresourcedefinitions", []string{"crd", "crds"}},
+}
+
+// kindAliases maps the plural and short names of builtinKinds to the
+// canonical singular kind.
+var kindAliases = func() map[string]string {
+	aliases := map[string]string{}
+	for _, k := range builtinKinds {
+		aliases[k.plural] = k.kind
+		for _, s := range k.short {
+			aliases[s] = k.kind
+		}
+	}
+	return aliases
+}()
+
+// NormalizeKind lowercases a resource kind and maps known aliases to their
+// canonical singular form. Unknown kinds (e.g. CRDs) are returned lowercased.
+func NormalizeKind(kind string) string {
+	k := strings.ToLower(strings.TrimSpace(kind))
+	if canon, ok := kindAliases[k]; ok {
+		return canon
+	}
+	return k
+}
+
+// Resource holds the fetched resource context from k9s + kubectl.
+type Resource struct {
+	Kind      string
+	Name      string
+	Namespace string
+	Context   string                 // kubeconfig context; empty for the current one
+	Vars      map[string]string      // placeholder values from the context and profiles, e.g. CLUSTER
+	RawJSON   []byte                 // full kubectl JSON output
+	Parsed    map[string]interface{} // unmarshaled for path traversal
+}
+
+// NewResource creates a Resource from CLI args. JSON is not yet fetched.
+func NewResource(kind, name, namespace string) *Resource {
+	if name == "" {
+		return nil
+	}
+	kind = NormalizeKind(kind)
+	if kind == "" {
+		kind = "pod"
+	}
+	return &Resource{
+		Kind:      kind,
+		Name:      name,
+		Namespace: namespace,
+	}
+}
+
+// FetchJSON calls kubectl to populate the resource's full JSON.
+func (r *Resource) FetchJSON() error {
+	args := []string{"get", r.Kind, r.Name, "-o", "json"}
+	if r.Namespace != "" {
+		args = append(args, "-n", r.Namespace)
+	}
+	if r.Context != "" {
+		args = append(args, "--context", r.Context)
+	}
+
+	cmd := exec.Command("kubectl", args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	r.RawJSON = out
+	var parsed map[string]interface{}
+	if err := json.Unmarshal(out, &parsed); err != nil {
+		return err
+	}
+	r.Parsed = parsed
+	return nil
+}
+
+// DisplayName returns "namespace/name", or just the name for cluster-scoped
+// resources.
+func (r *Resource) DisplayName() string {
+	if r.Namespace != "" {
+		return r.Namespace + "/" + r.Name
+	}
+	return r.Name
+}
+
+// ResolvePath walks the parsed JSON using a dot-separated path and returns
+// whatever value lives at that location (map, slice, string, number, etc.).
+// Keys containing dots can be bracketed or escaped, numeric segments index
+// into arrays and wildcard segments return a list; see parsePath.
+func (r *Resource) ResolvePath(path string) (interface{}, bool) {
+	segs, err := parsePath(path)
+	if err != nil {
+		return nil, false
+	}
+	return resolveSegments(r.Parsed, segs)
+}
+
+// Labels is a convenience method that extracts metadata.labels as map[string]string.
+func (r *Resource) Labels() map[string]string {
+	val, ok := r.ResolvePath("metadata.labels")
+	if !ok {
+		return map[string]string{}
+	}
+	m, ok := val.(map[string]interface{})
+	if !ok {
+		return map[string]string{}
+	}
+	result := make(map[string]string, len(m))
+	for k, v := range m {
+		result[k] = stringify(v)
+	}
+	return result
+}
+
+// FlattenPaths returns all dot-notation paths and their values from the parsed JSON,
+// sorted alphabetically. Each entry is "path = value", where path uses the same
+// syntax as ResolvePath so it can be pasted into config.json.
+func (r *Resource) FlattenPaths() []string {
+	var result []string
+	flattenRecurse("", r.Parsed, &result)
+	sort.Strings(result)
+	return result
+}
+
+func flattenRecurse(prefix string, val interface{}, out *[]string) {
+	switch v := val.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			flattenRecurse(joinPath(prefix, k), child, out)
+		}
+	case []interface{}:
+		for i, child := range v {
+			path := fmt.Sprintf("%s.%d", prefix, i)
+			flattenRecurse(path, child, out)
+		}
+	default:
+		*out = append(*out, fmt.Sprintf("%s = %s", prefix, stringify(val)))
+	}
+}
+
+// stringify converts an arbitrary JSON value to its string representation.
+// Lists (e.g. from wildcard paths) are joined with commas.
+func stringify(v interface{}) string {
+	switch val := v.(type) {
+	case []interface{}:
+		parts := make([]string, len(val))
+		for i, elem := range val {
+			parts[i] = stringify(elem)
+		}
+		return strings.Join(parts, ",")
+	case string:
+		return val
+	case float64:
+		if val == float64(int64(val)) {
+			return fmt.Sprintf("%d", int64(val))
+		}
+		return fmt.Sprintf("%g", val)
+	case bool:
+		return fmt.Sprintf("%t", val)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprintf("%v", val)
+	}
+}
+
+// FilterMenuItems returns only the menu items that apply to this resource.
+// If the Resource is nil (no resource context), items with kinds or
+// filters are excluded and all other items are kept. Items whose required
+// templateVars don't resolve, or whose urlTemplate fails without a url to
+// fall back to, are always excluded.
+func FilterMenuItems(items []MenuItem, r *Resource) []MenuItem {
+	var filtered []MenuItem
+	for _, item := range items {
+		if len(item.MissingRequired(r)) > 0 || item.TemplateError(r) != nil {
+			continue
+		}
+		if r == nil {
+			// No resource context: only show items that don't depend on one
+			if len(item.Kinds) == 0 && item.Filters.IsEmpty() {
+				filtered = append(filtered, item)
+			}
+			continue
+		}
+		if item.Matches(r) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// Var returns the value of a placeholder such as "$CLUSTER" (or "CLUSTER")
+// from r.Vars, or "" if it isn't set. It is safe on a nil resource.
+func (r *Resource) Var(ref string) string {
+	if r == nil {
+		return ""
+	}
+	return r.Vars[strings.TrimPrefix(ref, "$")]
+}
+
+// kubeContext fills in the context and cluster k9s didn't pass, from the
+// kubeconfig: the current context, and the cluster of the context.
+func kubeContext(context, cluster string) (string, string, error) {
+	if context != "" && cluster != "" {
+		return context, cluster, nil
+	}
+	args := []string{"config", "view", "--minify", "-o", `jsonpath={.contexts[0].name}{"\t"}{.contexts[0].context.cluster}`}
+	if context != "" {
+		args = append(args, "--context", context)
+	}
+	out, err := exec.Command("kubectl", args...).Output()
+	if err != nil {
+		return context, cluster, fmt.Errorf("kubectl config view: %w", err)
+	}
+	name, clusterName, _ := strings.Cut(strings.TrimSpace(string(out)), "\t")
+	if context == "" {
+		context = name
+	}
+	if cluster == "" {
+		cluster = clusterName
+	}
+	return context, cluster, nil
+}
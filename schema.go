@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// schemaDescriptions documents every config field, keyed by Go type and JSON
+// name. Generating the schema fails for fields missing here, which keeps the
+// schema in sync with the structs.
+var schemaDescriptions = map[string]string{
+	"Config":                 "go-to-dashboard configuration.",
+	"Config.$schema":         "JSON Schema for editor support, e.g. \"./config.schema.json\". Ignored by go-to-dashboard.",
+	"Config.include":         "Config files layered below this one, relative to it; globs and ~/ are allowed. Later files and this file override earlier ones.",
+	"Config.vars":            "Values for ${name} in titles, descriptions, urls and urlAppends. Values may use other vars and ${env:NAME}.",
+	"Config.profiles":        "Per-context settings. Every profile matching the current kube context applies, in order.",
+	"Config.conditionSets":   "Named lists of conditions that menu items add with use.",
+	"Config.templateVarSets": "Named lists of templateVars that menu items add with use.",
+	"Config.menuItems":       "Dashboards and links offered in the menu.",
+
+	"MenuItem":              "A dashboard or link in the menu.",
+	"MenuItem.id":           "Identifies the item across config layers: an item with the same id in a later layer replaces it.",
+	"MenuItem.disabled":     "With id: remove the item with this id from included configs.",
+	"MenuItem.description":  "Shown in the fzf preview pane.",
+	"MenuItem.title":        "Text shown in the fzf list.",
+	"MenuItem.url":          "Base URL to open. Optional with urlTemplate, where it is the fallback if the template fails.",
+	"MenuItem.urlTemplate":  "Go text/template that builds the whole URL from the resource JSON. Cannot be combined with templateVars.",
+	"MenuItem.kinds":        "Resource kinds this item applies to, e.g. [\"deployment\", \"statefulset\"]. Omit for every kind.",
+	"MenuItem.filters":      "Only show the item for resources passing these checks.",
+	"MenuItem.templateVars": "Values from the resource appended to url.",
+	"MenuItem.use":          "Names of conditionSets and templateVarSets whose entries come before filters.conditions and templateVars.",
+
+	"Profile":         "Settings for the kube contexts or clusters it matches.",
+	"Profile.name":    "Name shown by explain.",
+	"Profile.context": "Regex for the kubeconfig context name, implicitly anchored. Omit to match any context.",
+	"Profile.cluster": "Regex for the cluster name, implicitly anchored. Omit to match any cluster.",
+	"Profile.vars":    "Placeholder values, e.g. {\"ENV\": \"prod\"} for $ENV in urlAppend or value.",
+	"Profile.urls":    "Replacement url per menu item id.",
+
+	"ItemFilters":            "A group of checks that must all pass.",
+	"ItemFilters.conditions": "Conditions that must all match.",
+	"ItemFilters.expression": "expr-lang boolean expression over the resource, e.g. labels.app == \"nginx\".",
+	"ItemFilters.allOf":      "Groups that must all pass.",
+	"ItemFilters.anyOf":      "Groups of which at least one must pass.",
+	"ItemFilters.not":        "Group that must not pass.",
+
+	"Condition":                 "A check against a field of the resource JSON.",
+	"Condition.path":            "Dot-notation path into the resource JSON, e.g. metadata.labels or spec.containers.#.image.",
+	"Condition.keyPattern":      "Regex for map keys, implicitly anchored. Defaults to .*.",
+	"Condition.valuePattern":    "Regex for values, implicitly anchored. Defaults to .*. Not used with op.",
+	"Condition.op":              "Compare values with an operator instead of valuePattern.",
+	"Condition.value":           "Operand for op: a string, number or boolean, a list for in, none for exists.",
+	"Condition.valueFromPath":   "Path in the same resource whose value is the operand for op. op defaults to eq.",
+	"Condition.valueTransforms": "Transforms applied to the valueFromPath value.",
+	"Condition.invert":          "Negate the condition.",
+
+	"TemplateVar":               "A value from the resource inserted into the URL.",
+	"TemplateVar.path":          "Dot-notation path to the value, e.g. metadata.labels.app.",
+	"TemplateVar.value":         "Literal value used instead of path, e.g. \"now\" for a time transform.",
+	"TemplateVar.key":           "For map-valued paths: the key whose value to use.",
+	"TemplateVar.placeholder":   "Name of this value in urlAppend, e.g. $APP. Defaults to $VALUE.",
+	"TemplateVar.urlAppend":     "String appended to the URL with placeholders replaced. Optional when placeholder is set.",
+	"TemplateVar.raw":           "Insert the value without URL escaping.",
+	"TemplateVar.fallbackPaths": "Paths tried in order when path (and key) don't resolve.",
+	"TemplateVar.transforms":    "Steps applied in order to the resolved value.",
+	"TemplateVar.default":       "Value used when no path resolves or the transforms produce nothing.",
+	"TemplateVar.required":      "Hide the item when the value is still empty.",
+
+	"Transform":             "A step of a value pipeline. Which fields apply depends on type.",
+	"Transform.type":        "Kind of transform.",
+	"Transform.pattern":     "regexReplace, regexCapture: unanchored regex.",
+	"Transform.replacement": "regexReplace: replacement, may use $1 etc.",
+	"Transform.group":       "regexCapture: capture group. Defaults to 1, or the whole match if there are no groups.",
+	"Transform.separator":   "split: separator.",
+	"Transform.index":       "split: element to keep; negative counts from the end.",
+	"Transform.value":       "trimPrefix, trimSuffix: string to remove.",
+	"Transform.length":      "truncate: maximum number of characters.",
+	"Transform.format":      "time: output format. Defaults to rfc3339.",
+	"Transform.offset":      "time: duration added to the timestamp, e.g. \"-5m\".",
+	"Transform.pick":        "time: which of several timestamps to use. Defaults to latest.",
+}
+
+// schemaEnums lists the allowed values of string fields.
+var schemaEnums = map[string][]string{
+	"Condition.op":     sortedKeys(knownOps),
+	"Transform.type":   {"regexReplace", "regexCapture", "split", "trimPrefix", "trimSuffix", "lower", "upper", "truncate", "time"},
+	"Transform.format": sortedKeys(timeFormats),
+	"Transform.pick":   {"latest", "earliest"},
+}
+
+// schemaPatterns are regexes string fields must match.
+var schemaPatterns = map[string]string{
+	"TemplateVar.placeholder": "^" + placeholderRe.String() + "$",
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// GenerateSchema builds a JSON Schema for Config from the Go types. Fields
+// without omitempty are required, and objects don't allow unknown keys.
+func GenerateSchema() (map[string]interface{}, error) {
+	g := &schemaGenerator{defs: map[string]interface{}{}}
+	root, err := g.object(reflect.TypeOf(Config{}))
+	if err != nil {
+		return nil, err
+	}
+	root["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	root["title"] = "go-to-dashboard config"
+	root["$defs"] = g.defs
+	return root, nil
+}
+
+type schemaGenerator struct {
+	defs map[string]interface{}
+}
+
+// object returns the schema of struct type t.
+func (g *schemaGenerator) object(t reflect.Type) (map[string]interface{}, error) {
+	props := map[string]interface{}{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		tag := strings.Split(f.Tag.Get("json"), ",")
+		name := tag[0]
+		if name == "" || name == "-" {
+			return nil, fmt.Errorf("schema: %s.%s has no json name", t.Name(), f.Name)
+		}
+		key := t.Name() + "." + name
+		desc, ok := schemaDescriptions[key]
+		if !ok {
+			return nil, fmt.Errorf("schema: %s has no description", key)
+		}
+		prop, err := g.typeSchema(f.Type)
+		if err != nil {
+			return nil, err
+		}
+		prop["description"] = desc
+		if enum, ok := schemaEnums[key]; ok {
+			prop["enum"] = enum
+		}
+		if pattern, ok := schemaPatterns[key]; ok {
+			prop["pattern"] = pattern
+		}
+		props[name] = prop
+		if len(tag) == 1 || tag[1] != "omitempty" {
+			required = append(required, name)
+		}
+	}
+	s := map[string]interface{}{
+		"type":                 "object",
+		"description":          schemaDescriptions[t.Name()],
+		"properties":           props,
+		"additionalProperties": false,
+	}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s, nil
+}
+
+// typeSchema returns the schema of a field type. Structs become $defs
+// entries so recursive types (ItemFilters) can refer to themselves.
+func (g *schemaGenerator) typeSchema(t reflect.Type) (map[string]interface{}, error) {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return g.typeSchema(t.Elem())
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}, nil
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}, nil
+	case reflect.Int:
+		return map[string]interface{}{"type": "integer"}, nil
+	case reflect.Interface:
+		return map[string]interface{}{}, nil
+	case reflect.Map:
+		values, err := g.typeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "object", "additionalProperties": values}, nil
+	case reflect.Slice:
+		items, err := g.typeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "array", "items": items}, nil
+	case reflect.Struct:
+		if _, ok := g.defs[t.Name()]; !ok {
+			g.defs[t.Name()] = nil // placeholder while recursing
+			def, err := g.object(t)
+			if err != nil {
+				return nil, err
+			}
+			g.defs[t.Name()] = def
+		}
+		return map[string]interface{}{"$ref": "#/$defs/" + t.Name()}, nil
+	}
+	return nil, fmt.Errorf("schema: unsupported type %s", t)
+}
+
+// runSchema implements the schema subcommand.
+func runSchema(stdout io.Writer) error {
+	schema, err := GenerateSchema()
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(schema)
+}
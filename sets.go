@@ -0,0 +1,29 @@
+package main
+
+import "fmt"
+
+// applyUses adds the conditionSets and templateVarSets named in the i-th
+// item's use before its own conditions and templateVars, and clears use so
+// validating again doesn't add them twice. A name may be defined in both
+// kinds of set; then both apply. Unless strict, unknown names are skipped,
+// for linting a config whose sets may come from an include.
+func (cfg *Config) applyUses(i int, item *MenuItem, strict bool) error {
+	if len(item.Use) == 0 {
+		return nil
+	}
+	var conds []Condition
+	var vars []TemplateVar
+	for _, name := range item.Use {
+		cs, isConds := cfg.ConditionSets[name]
+		vs, isVars := cfg.TemplateVarSets[name]
+		if !isConds && !isVars && strict {
+			return fmt.Errorf("config: %s (%s) use: no conditionSet or templateVarSet %q", itemLabel(i, item), item.Title, name)
+		}
+		conds = append(conds, cs...)
+		vars = append(vars, vs...)
+	}
+	item.Filters.Conditions = append(conds, item.Filters.Conditions...)
+	item.TemplateVars = append(vars, item.TemplateVars...)
+	item.Use = nil
+	return nil
+}
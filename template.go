@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+)
+
+// parseURLTemplate compiles a urlTemplate. The helper functions are bound to
+// a resource at render time, so placeholders are registered here. Every
+// action's output is escaped for where it lands in the URL (see
+// escapeActions).
+func parseURLTemplate(name, text string) (*template.Template, error) {
+	t, err := template.New(name).Option("missingkey=zero").Funcs(templateFuncs(nil)).Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range t.Templates() {
+		if sub.Tree != nil {
+			escapeActions(sub.Tree, sub.Tree.Root, "")
+		}
+	}
+	return t, nil
+}
+
+// unescapedFuncs are the helpers that end a pipeline whose output must not
+// be escaped again.
+var unescapedFuncs = map[string]bool{"raw": true, "urlquery": true, "pathescape": true}
+
+// escapeActions appends escapePath or escapeQuery to every action in list
+// that prints a value, chosen like for templateVars from the literal text
+// before it: query escaping after a '?', path escaping before. prefix is the
+// literal text so far; the text after list is returned. Pipelines that
+// already end in raw, urlquery or pathescape are left alone.
+func escapeActions(tree *parse.Tree, list *parse.ListNode, prefix string) string {
+	if list == nil {
+		return prefix
+	}
+	for _, node := range list.Nodes {
+		switch n := node.(type) {
+		case *parse.TextNode:
+			prefix += string(n.Text)
+		case *parse.ActionNode:
+			if len(n.Pipe.Decl) > 0 || len(n.Pipe.Cmds) == 0 {
+				continue
+			}
+			last := n.Pipe.Cmds[len(n.Pipe.Cmds)-1]
+			if id, ok := last.Args[0].(*parse.IdentifierNode); ok && unescapedFuncs[id.Ident] {
+				continue
+			}
+			escape := "escapePath"
+			if inQuery(prefix) {
+				escape = "escapeQuery"
+			}
+			n.Pipe.Cmds = append(n.Pipe.Cmds, &parse.CommandNode{
+				NodeType: parse.NodeCommand,
+				Pos:      n.Pos,
+				Args:     []parse.Node{parse.NewIdentifier(escape).SetTree(tree).SetPos(n.Pos)},
+			})
+		case *parse.IfNode:
+			prefix = escapeBranch(tree, &n.BranchNode, prefix)
+		case *parse.WithNode:
+			prefix = escapeBranch(tree, &n.BranchNode, prefix)
+		case *parse.RangeNode:
+			prefix = escapeBranch(tree, &n.BranchNode, prefix)
+		}
+	}
+	return prefix
+}
+
+// escapeBranch escapes both lists of an if, with or range against prefix and
+// returns prefix followed by the literal text of both.
+func escapeBranch(tree *parse.Tree, b *parse.BranchNode, prefix string) string {
+	body := escapeActions(tree, b.List, prefix)
+	alt := escapeActions(tree, b.ElseList, prefix)
+	return body + alt[len(prefix):]
+}
+
+// escapeValue returns an escaping helper for urlTemplate actions. A nil value
+// is a field the resource doesn't have and fails the render.
+func escapeValue(escape func(string) string) func(interface{}) (string, error) {
+	return func(val interface{}) (string, error) {
+		if val == nil {
+			return "", fmt.Errorf("field not found")
+		}
+		return escape(stringify(val)), nil
+	}
+}
+
+// noValue is what text/template prints for a field the resource doesn't have.
+const noValue = "<no value>"
+
+// renderURLTemplate executes t with the resource JSON as dot and the helper
+// functions bound to r. A nil resource renders against an empty object. Like
+// an exec error (e.g. a field of a missing object), a field that doesn't
+// exist fails the render instead of leaving "<no value>" in the URL.
+func renderURLTemplate(t *template.Template, r *Resource) (string, error) {
+	t, err := t.Clone()
+	if err != nil {
+		return "", err
+	}
+	t.Funcs(templateFuncs(r))
+	data := map[string]interface{}{}
+	if r != nil && r.Parsed != nil {
+		data = r.Parsed
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, data); err != nil {
+		return "", err
+	}
+	if strings.Contains(b.String(), noValue) {
+		return "", fmt.Errorf("template: %s: field not found", t.Name())
+	}
+	return b.String(), nil
+}
+
+// templateFuncs returns the helpers available in urlTemplate. Lookups on a
+// nil resource or a missing path yield "" so they compose with default.
+func templateFuncs(r *Resource) template.FuncMap {
+	lookup := func(path string) interface{} {
+		if r == nil {
+			return ""
+		}
+		val, ok := r.ResolvePath(path)
+		if !ok || val == nil {
+			return ""
+		}
+		return val
+	}
+	return template.FuncMap{
+		"path": lookup,
+		"label": func(key string) string {
+			return stringify(lookup(joinPath("metadata.labels", key)))
+		},
+		"annotation": func(key string) string {
+			return stringify(lookup(joinPath("metadata.annotations", key)))
+		},
+		"default": func(def string, val interface{}) interface{} {
+			if isEmptyValue(val) {
+				return def
+			}
+			return val
+		},
+		"var":         r.Var,
+		"raw":         func(val interface{}) interface{} { return val },
+		"pathescape":  url.PathEscape,
+		"escapePath":  escapeValue(url.PathEscape),
+		"escapeQuery": escapeValue(url.QueryEscape),
+		"lower":       func(val interface{}) string { return strings.ToLower(stringify(val)) },
+		"upper":       func(val interface{}) string { return strings.ToUpper(stringify(val)) },
+		"now":         func() time.Time { return timeNow() },
+		"parseTime": func(val interface{}) (time.Time, error) {
+			t, ok := pickTimestamp(stringify(val), false)
+			if !ok {
+				return time.Time{}, fmt.Errorf("parseTime: no RFC3339 timestamp in %q", stringify(val))
+			}
+			return t, nil
+		},
+		"offset": func(d string, t time.Time) (time.Time, error) {
+			dur, err := time.ParseDuration(d)
+			if err != nil {
+				return time.Time{}, err
+			}
+			return t.Add(dur), nil
+		},
+		"epochMillis":  timeFormats["epochMillis"],
+		"epochSeconds": timeFormats["epochSeconds"],
+		"rfc3339":      timeFormats["rfc3339"],
+		"relative":     timeFormats["relative"],
+		"join": func(sep string, val interface{}) string {
+			list, ok := val.([]interface{})
+			if !ok {
+				return stringify(val)
+			}
+			parts := make([]string, len(list))
+			for i, elem := range list {
+				parts[i] = stringify(elem)
+			}
+			return strings.Join(parts, sep)
+		},
+	}
+}
+
+// isEmptyValue reports whether a template value counts as missing for default.
+func isEmptyValue(val interface{}) bool {
+	switch v := val.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case []interface{}:
+		return len(v) == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	}
+	return false
+}
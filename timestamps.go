@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// timeNow is the clock used for "now" in time transforms, template helpers
+// and time-relative conditions. Tests replace it.
+var timeNow = time.Now
+
+// timeFormats are the outputs supported by the time transform and helpers.
+var timeFormats = map[string]func(time.Time) string{
+	"epochMillis":  func(t time.Time) string { return fmt.Sprint(t.UnixMilli()) },
+	"epochSeconds": func(t time.Time) string { return fmt.Sprint(t.Unix()) },
+	"rfc3339":      func(t time.Time) string { return t.UTC().Format(time.RFC3339) },
+	"relative":     relativeTime,
+}
+
+// pickTimestamp parses val as an RFC3339 timestamp, or "now". Comma-separated
+// lists (from wildcard paths such as every container's finishedAt) yield the
+// latest timestamp, or the earliest if requested. Unparseable entries are
+// ignored.
+func pickTimestamp(val string, earliest bool) (time.Time, bool) {
+	var picked time.Time
+	found := false
+	for _, s := range strings.Split(val, ",") {
+		s = strings.TrimSpace(s)
+		var t time.Time
+		if s == "now" {
+			t = timeNow()
+		} else {
+			var err error
+			if t, err = time.Parse(time.RFC3339, s); err != nil {
+				continue
+			}
+		}
+		if !found || (earliest && t.Before(picked)) || (!earliest && t.After(picked)) {
+			picked, found = t, true
+		}
+	}
+	return picked, found
+}
+
+// relativeTime formats t as an offset from now in whole minutes, e.g.
+// "now-65m", as understood by Grafana. Times in the future are "now".
+func relativeTime(t time.Time) string {
+	d := timeNow().Sub(t)
+	if d <= 0 {
+		return "now"
+	}
+	return fmt.Sprintf("now-%dm", int64(math.Ceil(d.Minutes())))
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Transform is one step of a templateVar's value pipeline. Which fields are
+// used depends on Type.
+type Transform struct {
+	Type        string `json:"type"`                  // regexReplace, regexCapture, split, trimPrefix, trimSuffix, lower, upper, truncate, time
+	Pattern     string `json:"pattern,omitempty"`     // regexReplace, regexCapture: unanchored regex
+	Replacement string `json:"replacement,omitempty"` // regexReplace: replacement, may use $1 etc.
+	Group       int    `json:"group,omitempty"`       // regexCapture: capture group; defaults to 1, or the whole match if there are no groups
+	Separator   string `json:"separator,omitempty"`   // split: separator
+	Index       int    `json:"index,omitempty"`       // split: element to keep; negative counts from the end
+	Value       string `json:"value,omitempty"`       // trimPrefix, trimSuffix: string to remove
+	Length      int    `json:"length,omitempty"`      // truncate: maximum number of characters
+	Format      string `json:"format,omitempty"`      // time: epochMillis, epochSeconds, rfc3339 (default) or relative
+	Offset      string `json:"offset,omitempty"`      // time: duration added to the timestamp, e.g. "-5m" for padding
+	Pick        string `json:"pick,omitempty"`        // time: latest (default) or earliest of several timestamps
+
+	// compiled fields (populated by ValidateConfig, not serialized)
+	re     *regexp.Regexp
+	offset time.Duration
+}
+
+// compile validates the transform's fields for its type and compiles its regex.
+func (t *Transform) compile() error {
+	switch t.Type {
+	case "regexReplace", "regexCapture":
+		if t.Pattern == "" {
+			return fmt.Errorf("%s needs a pattern", t.Type)
+		}
+		re, err := regexp.Compile(t.Pattern)
+		if err != nil {
+			return fmt.Errorf("%s invalid pattern %q: %w", t.Type, t.Pattern, err)
+		}
+		if t.Type == "regexCapture" {
+			if t.Group == 0 && re.NumSubexp() > 0 {
+				t.Group = 1
+			}
+			if t.Group < 0 || t.Group > re.NumSubexp() {
+				return fmt.Errorf("regexCapture pattern %q has no group %d", t.Pattern, t.Group)
+			}
+		}
+		t.re = re
+	case "split":
+		if t.Separator == "" {
+			return fmt.Errorf("split needs a separator")
+		}
+	case "trimPrefix", "trimSuffix":
+		if t.Value == "" {
+			return fmt.Errorf("%s needs a value", t.Type)
+		}
+	case "truncate":
+		if t.Length <= 0 {
+			return fmt.Errorf("truncate needs a positive length")
+		}
+	case "time":
+		if t.Format == "" {
+			t.Format = "rfc3339"
+		}
+		if _, ok := timeFormats[t.Format]; !ok {
+			return fmt.Errorf("time has unknown format %q", t.Format)
+		}
+		if t.Offset != "" {
+			d, err := time.ParseDuration(t.Offset)
+			if err != nil {
+				return fmt.Errorf("time invalid offset %q: %w", t.Offset, err)
+			}
+			t.offset = d
+		}
+		if t.Pick != "" && t.Pick != "latest" && t.Pick != "earliest" {
+			return fmt.Errorf("time pick must be latest or earliest, got %q", t.Pick)
+		}
+	case "lower", "upper":
+	case "":
+		return fmt.Errorf("transform has no type")
+	default:
+		return fmt.Errorf("unknown transform type %q", t.Type)
+	}
+	return nil
+}
+
+// apply runs the transform on val. Steps that find nothing (no regex match,
+// split index out of range) return "".
+func (t *Transform) apply(val string) string {
+	switch t.Type {
+	case "regexReplace":
+		return t.re.ReplaceAllString(val, t.Replacement)
+	case "regexCapture":
+		m := t.re.FindStringSubmatch(val)
+		if m == nil {
+			return ""
+		}
+		return m[t.Group]
+	case "split":
+		parts := strings.Split(val, t.Separator)
+		i := t.Index
+		if i < 0 {
+			i += len(parts)
+		}
+		if i < 0 || i >= len(parts) {
+			return ""
+		}
+		return parts[i]
+	case "trimPrefix":
+		return strings.TrimPrefix(val, t.Value)
+	case "trimSuffix":
+		return strings.TrimSuffix(val, t.Value)
+	case "lower":
+		return strings.ToLower(val)
+	case "upper":
+		return strings.ToUpper(val)
+	case "truncate":
+		if r := []rune(val); len(r) > t.Length {
+			return string(r[:t.Length])
+		}
+	case "time":
+		ts, ok := pickTimestamp(val, t.Pick == "earliest")
+		if !ok {
+			return ""
+		}
+		return timeFormats[t.Format](ts.Add(t.offset))
+	}
+	return val
+}
+
+// applyTransforms runs val through each transform in order.
+func applyTransforms(ts []Transform, val string) string {
+	for i := range ts {
+		val = ts[i].apply(val)
+	}
+	return val
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// varRefRe matches ${name} references to config vars and ${env:NAME}
+// references to environment variables.
+var varRefRe = regexp.MustCompile(`\$\{(env:)?([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// resolveConfigVars expands references between config vars and to the
+// environment, and reports undefined vars and cycles. Unless strictEnv,
+// unset environment variables are left as they are (see lookupEnv).
+func resolveConfigVars(vars map[string]string, strictEnv bool) (map[string]string, error) {
+	resolved := map[string]string{}
+	var resolve func(name string, stack []string) (string, error)
+	resolve = func(name string, stack []string) (string, error) {
+		if v, ok := resolved[name]; ok {
+			return v, nil
+		}
+		for i, s := range stack {
+			if s == name {
+				return "", fmt.Errorf("cycle %s", strings.Join(append(stack[i:], name), " -> "))
+			}
+		}
+		raw, ok := vars[name]
+		if !ok {
+			return "", fmt.Errorf("undefined var ${%s}", name)
+		}
+		v, err := expandVarRefs(raw, func(ref string) (string, error) {
+			if env, ok := strings.CutPrefix(ref, "env:"); ok {
+				return lookupEnv(env, strictEnv)
+			}
+			return resolve(ref, append(stack, name))
+		})
+		if err != nil {
+			return "", err
+		}
+		resolved[name] = v
+		return v, nil
+	}
+	for _, name := range sortedKeys(vars) {
+		if !varRefRe.MatchString("${" + name + "}") {
+			return nil, fmt.Errorf("config: vars: invalid name %q (want letters, digits or _)", name)
+		}
+		if _, err := resolve(name, nil); err != nil {
+			return nil, fmt.Errorf("config: vars.%s: %w", name, err)
+		}
+	}
+	return resolved, nil
+}
+
+// expandVarRefs replaces ${name} with lookup(name) and ${env:NAME} with
+// lookup("env:NAME").
+func expandVarRefs(s string, lookup func(name string) (string, error)) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+	if rest := varRefRe.ReplaceAllString(s, ""); strings.Contains(rest, "${") {
+		return "", fmt.Errorf("malformed var reference in %q (want ${name} or ${env:NAME})", s)
+	}
+	var err error
+	out := varRefRe.ReplaceAllStringFunc(s, func(m string) string {
+		sub := varRefRe.FindStringSubmatch(m)
+		v, lookupErr := lookup(sub[1] + sub[2])
+		if lookupErr != nil && err == nil {
+			err = lookupErr
+		}
+		return v
+	})
+	return out, err
+}
+
+// expandItemVars expands config vars in the i-th item's title, description,
+// url and urlAppends.
+func expandItemVars(i int, item *MenuItem, lookup func(string) (string, error)) error {
+	expand := func(field string, val *string) error {
+		v, err := expandVarRefs(*val, lookup)
+		if err != nil {
+			return fmt.Errorf("config: %s (%s) %s: %w", itemLabel(i, item), item.Title, field, err)
+		}
+		*val = v
+		return nil
+	}
+	if err := expand("title", &item.Title); err != nil {
+		return err
+	}
+	if err := expand("description", &item.Description); err != nil {
+		return err
+	}
+	if err := expand("url", &item.URL); err != nil {
+		return err
+	}
+	for j := range item.TemplateVars {
+		if err := expand(fmt.Sprintf("templateVars[%d] urlAppend", j), &item.TemplateVars[j].URLAppend); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// varLookup returns a lookup for expandVarRefs over resolved vars. Unless
+// strict, undefined vars are left as they are, for linting a config whose
+// vars may come from an include; strictEnv is passed to lookupEnv.
+func varLookup(vars map[string]string, strict, strictEnv bool) func(string) (string, error) {
+	return func(name string) (string, error) {
+		if env, ok := strings.CutPrefix(name, "env:"); ok {
+			return lookupEnv(env, strictEnv)
+		}
+		v, ok := vars[name]
+		switch {
+		case ok:
+			return v, nil
+		case strict:
+			return "", fmt.Errorf("undefined var ${%s}", name)
+		}
+		return "${" + name + "}", nil
+	}
+}
+
+// lookupEnv returns the environment variable for an ${env:NAME} reference.
+// Unless strict, an unset variable is left as it is, for linting a config
+// where the environment it runs with isn't available (e.g. in CI).
+func lookupEnv(name string, strict bool) (string, error) {
+	if v, ok := os.LookupEnv(name); ok {
+		return v, nil
+	}
+	if strict {
+		return "", fmt.Errorf("environment variable %s is not set", name)
+	}
+	return "${env:" + name + "}", nil
+}